@@ -0,0 +1,113 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrCycle is returned when a pointer references its ancestor
+// and the option ReturnCycleError is enabled.
+var ErrCycle = errors.New("cyclic pointer reference")
+
+// FieldError represents an error returned by the handler of a struct field.
+type FieldError struct {
+	// Path is the full path of the field from the root struct,
+	// such as "Response.Persons[3].Username".
+	//
+	// If the root struct type is unnamed, the path starts with the field.
+	Path string
+
+	// NamePath is the same as Path, but consists of the names of the fields
+	// like Name and does not start with the root struct type, such as
+	// "persons[3].username", which is used by Error to avoid exposing
+	// the Go identifiers, for example, to the API clients.
+	NamePath string
+
+	Field string // The name of the struct field, such as "Username".
+	Tag   string // The tag name of the handler, such as "validate".
+	Value string // The unquoted tag value, such as "min(1)".
+	Err   error  // The original error returned by the handler.
+
+	// Name is the name of the struct field returned by
+	// defaults.GetStructFieldName, which respects defaults.StructFieldNameFunc,
+	// such as "username" of the tag `json:"username"`.
+	//
+	// If the function returns an empty name, it is the same as Field.
+	Name string
+}
+
+// Error implements the interface error, which is "NamePath: Err",
+// or "Path: Err" if NamePath is empty.
+func (e *FieldError) Error() string {
+	if e.NamePath == "" {
+		return e.Path + ": " + e.Err.Error()
+	}
+	return e.NamePath + ": " + e.Err.Error()
+}
+
+// Unwrap returns the original error returned by the handler.
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// ConfigError represents a configuration error of the struct field,
+// such as the invalid tag value, or that the field does not match the tag.
+type ConfigError struct {
+	Type  reflect.Type // The type of the struct containing the field.
+	Field string       // The name of the struct field.
+	Tag   string       // The tag name of the handler.
+	Value string       // The unquoted tag value if possible.
+	Err   error
+}
+
+// Error implements the interface error.
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%s.%s: invalid tag '%s' value '%s': %s", e.Type, e.Field, e.Tag, e.Value, e.Err)
+}
+
+// Unwrap returns the original error.
+func (e *ConfigError) Unwrap() error { return e.Err }
+
+// MultiError represents a list of errors returned by the field handlers
+// when collecting all the errors.
+//
+// Like the error returned by errors.Join, it supports errors.Is and errors.As
+// by the method Unwrap() []error.
+type MultiError []error
+
+// Error implements the interface error, which joins the error messages
+// with a newline, the same as errors.Join.
+func (es MultiError) Error() string {
+	switch len(es) {
+	case 0:
+		return ""
+	case 1:
+		return es[0].Error()
+	}
+
+	var b strings.Builder
+	for i, err := range es {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap returns the list of the errors.
+func (es MultiError) Unwrap() []error { return es }
@@ -0,0 +1,392 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Arg is an argument of the tag value parsed by ParseArgs.
+type Arg struct {
+	Key    string   // The key of "key=value", which is empty for the positional value or flag.
+	Value  string   // The scalar value, which is empty for the list.
+	List   []string // The list value like "[a, b]", which is nil for the scalar.
+	Quoted bool     // Whether the scalar value is quoted.
+}
+
+// ParseArgs parses the tag value as a list of the arguments separated
+// by the comma, each of which is one of the forms as follow:
+//
+//	value          // A positional value, or a flag if it is the name of a bool option.
+//	key=value      // A named value.
+//	'a, b' or "a"  // A quoted value, which may contain the comma, "=" or "]".
+//	[a, 'b', c]    // A list of the values, which may be used as the value of key=value.
+//
+// The spaces around the key and value are trimmed, and the backslash
+// escapes the next character in the quoted value.
+//
+// Example:
+//
+//	ParseArgs(`1, 10, step=2, exclusive, tags=[a, 'b,c']`)
+func ParseArgs(s string) (args []Arg, err error) {
+	p := argParser{s: s}
+	if p.skipSpaces(); p.eof() {
+		return
+	}
+
+	for {
+		var arg Arg
+		if arg, err = p.value(); err != nil {
+			return nil, err
+		}
+
+		if p.skipSpaces(); p.next('=') {
+			if arg.List != nil || arg.Quoted || arg.Value == "" {
+				return nil, p.errorf("invalid key")
+			}
+
+			key := arg.Value
+			if arg, err = p.value(); err != nil {
+				return nil, err
+			}
+			arg.Key = key
+		} else if arg.List == nil && !arg.Quoted && arg.Value == "" {
+			return nil, p.errorf("missing argument")
+		}
+		args = append(args, arg)
+
+		if p.skipSpaces(); p.eof() {
+			return args, nil
+		} else if !p.next(',') {
+			return nil, p.errorf("expect ','")
+		}
+		p.skipSpaces()
+	}
+}
+
+type argParser struct {
+	s string
+	i int
+}
+
+func (p *argParser) eof() bool { return p.i >= len(p.s) }
+
+func (p *argParser) errorf(msg string) error {
+	return fmt.Errorf("%s at offset %d of '%s'", msg, p.i, p.s)
+}
+
+func (p *argParser) skipSpaces() {
+	for p.i < len(p.s) && p.s[p.i] == ' ' {
+		p.i++
+	}
+}
+
+func (p *argParser) next(c byte) bool {
+	if p.i < len(p.s) && p.s[p.i] == c {
+		p.i++
+		return true
+	}
+	return false
+}
+
+// value parses a scalar or list value.
+func (p *argParser) value() (arg Arg, err error) {
+	p.skipSpaces()
+	if !p.next('[') {
+		arg.Value, arg.Quoted, err = p.scalar()
+		return
+	}
+
+	arg.List = []string{}
+	if p.skipSpaces(); p.next(']') {
+		return
+	}
+
+	for {
+		value, quoted, err := p.scalar()
+		if err != nil {
+			return arg, err
+		} else if value == "" && !quoted {
+			return arg, p.errorf("missing list element")
+		}
+		arg.List = append(arg.List, value)
+
+		switch p.skipSpaces(); {
+		case p.next(']'):
+			return arg, nil
+		case !p.next(','):
+			return arg, p.errorf("expect ',' or ']'")
+		}
+		p.skipSpaces()
+	}
+}
+
+// scalar parses a quoted or bare value.
+func (p *argParser) scalar() (value string, quoted bool, err error) {
+	if p.eof() || (p.s[p.i] != '\'' && p.s[p.i] != '"') {
+		start := p.i
+		for p.i < len(p.s) && !strings.ContainsRune(",=[]'\"", rune(p.s[p.i])) {
+			p.i++
+		}
+		return strings.TrimSpace(p.s[start:p.i]), false, nil
+	}
+
+	var b strings.Builder
+	quote := p.s[p.i]
+	for p.i++; p.i < len(p.s); p.i++ {
+		switch c := p.s[p.i]; {
+		case c == quote:
+			p.i++
+			return b.String(), true, nil
+		case c == '\\' && p.i+1 < len(p.s):
+			p.i++
+			b.WriteByte(p.s[p.i])
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", false, p.errorf("unterminated quoted value")
+}
+
+// DecodeArgs parses the tag value s by ParseArgs and decodes the arguments
+// into the options struct pointed by dst, and calls its method Validate
+// if it implements the interface { Validate() error }.
+//
+// The option name of the struct field is the lower-case field name,
+// which can be overridden by the tag "arg" like `arg:"name,opt1,opt2"`.
+// And the options of the tag are as follow:
+//
+//	pos       // The field is set by the positional value in the order of fields.
+//	required  // The field must be set.
+//
+// The field tagged by `arg:"-"` or unexported is ignored. The field type
+// may be bool, string, int*, uint*, float*, time.Duration, the type
+// implementing encoding.TextUnmarshaler, or a slice of them, which is set
+// by the list or a scalar value.
+func DecodeArgs(s string, dst any) error {
+	args, err := ParseArgs(s)
+	if err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("the options %T is not a pointer to struct", dst)
+	}
+
+	v = v.Elem()
+	opts := getArgOptions(v.Type())
+	var pos int
+	for _, arg := range args {
+		var opt *argOption
+		switch {
+		case arg.Key != "":
+			if opt = opts.lookup(arg.Key); opt == nil {
+				return fmt.Errorf("unknown option '%s'", arg.Key)
+			}
+
+		case arg.List == nil && !arg.Quoted && opts.isFlag(arg.Value):
+			opt, arg.Value = opts.lookup(arg.Value), "true"
+
+		default:
+			for pos < len(opts) && !opts[pos].pos {
+				pos++
+			}
+			if pos == len(opts) {
+				return fmt.Errorf("unexpected positional value '%s'", arg.Value)
+			}
+			opt = &opts[pos]
+			pos++
+		}
+
+		if opt.set {
+			return fmt.Errorf("duplicate option '%s'", opt.name)
+		}
+		opt.set = true
+
+		if err := setArg(v.Field(opt.index), arg); err != nil {
+			return fmt.Errorf("invalid option '%s': %w", opt.name, err)
+		}
+	}
+
+	for _, opt := range opts {
+		if opt.required && !opt.set {
+			return fmt.Errorf("missing option '%s'", opt.name)
+		}
+	}
+
+	if validator, ok := dst.(interface{ Validate() error }); ok {
+		return validator.Validate()
+	}
+	return nil
+}
+
+// ParseOptions is the same as DecodeArgs, but decodes the tag value
+// into a new options of type T, which can be used by Typed.
+func ParseOptions[T any](s string) (opts T, err error) {
+	err = DecodeArgs(s, &opts)
+	return
+}
+
+// OptionsParser returns a Parser to decode the tag value
+// into a new options of type T by ParseOptions, which can be used by New.
+func OptionsParser[T any]() Parser {
+	return func(s string) (any, error) { return ParseOptions[T](s) }
+}
+
+type argOption struct {
+	index    int
+	name     string
+	pos      bool
+	required bool
+	flag     bool
+	set      bool
+}
+
+type argOptions []argOption
+
+func (opts argOptions) lookup(name string) *argOption {
+	for i := range opts {
+		if opts[i].name == name {
+			return &opts[i]
+		}
+	}
+	return nil
+}
+
+func (opts argOptions) isFlag(name string) bool {
+	opt := opts.lookup(name)
+	return opt != nil && opt.flag
+}
+
+func getArgOptions(t reflect.Type) argOptions {
+	opts := make(argOptions, 0, t.NumField())
+	for i, _len := 0, t.NumField(); i < _len; i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		opt := argOption{index: i, name: strings.ToLower(sf.Name)}
+		if tag := sf.Tag.Get("arg"); tag == "-" {
+			continue
+		} else if tag != "" {
+			name, flags, _ := strings.Cut(tag, ",")
+			if name = strings.TrimSpace(name); name != "" {
+				opt.name = name
+			}
+
+			for _, flag := range strings.Split(flags, ",") {
+				switch strings.TrimSpace(flag) {
+				case "pos":
+					opt.pos = true
+				case "required":
+					opt.required = true
+				}
+			}
+		}
+
+		opt.flag = !opt.pos && sf.Type.Kind() == reflect.Bool
+		opts = append(opts, opt)
+	}
+	return opts
+}
+
+var (
+	durationType        = reflect.TypeFor[time.Duration]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+func setArg(v reflect.Value, arg Arg) error {
+	if v.Kind() == reflect.Slice && !reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) {
+		values := arg.List
+		if values == nil {
+			values = []string{arg.Value}
+		}
+
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setScalar(slice.Index(i), value); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+
+	if arg.List != nil {
+		return errors.New("not support the list")
+	}
+	return setScalar(v, arg.Value)
+}
+
+func setScalar(v reflect.Value, s string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == durationType {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(i)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler_test
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/xgfone/go-structs"
+	"github.com/xgfone/go-structs/handler"
+)
+
+type RangeOptions struct {
+	Min       int `arg:",pos,required"`
+	Max       int `arg:",pos,required"`
+	Step      int
+	Exclusive bool
+	Tags      []string
+}
+
+func (o RangeOptions) Validate() error {
+	if o.Min > o.Max {
+		return errors.New("min must not be greater than max")
+	}
+	return nil
+}
+
+func ExampleParseArgs() {
+	args, err := handler.ParseArgs(`1, 10, step=2, exclusive, tags=[a, 'b,c'], name="x=y"`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, arg := range args {
+		fmt.Printf("key=%q, value=%q, list=%q\n", arg.Key, arg.Value, arg.List)
+	}
+
+	// Output:
+	// key="", value="1", list=[]
+	// key="", value="10", list=[]
+	// key="step", value="2", list=[]
+	// key="", value="exclusive", list=[]
+	// key="tags", value="", list=["a" "b,c"]
+	// key="name", value="x=y", list=[]
+}
+
+func ExampleParseOptions() {
+	sf := structs.NewReflector()
+	sf.Register("range", handler.Typed(handler.ParseOptions[RangeOptions],
+		func(_ any, field *int, opts RangeOptions) error {
+			if *field < opts.Min || *field > opts.Max || (opts.Exclusive && *field == opts.Max) {
+				return fmt.Errorf("%d is not in the range [%d, %d] %v", *field, opts.Min, opts.Max, opts.Tags)
+			}
+			return nil
+		}))
+
+	type S struct {
+		Count int `range:"1, 10, step=2, exclusive, tags=[a, 'b,c']"`
+	}
+
+	fmt.Println(sf.Reflect(&S{Count: 10}))
+
+	for _, s := range []string{"1", "10, 1", "1, 10, unknown=1", "1, 10, step=abc"} {
+		_, err := handler.ParseOptions[RangeOptions](s)
+		fmt.Println(err)
+	}
+
+	// Output:
+	// Count: 10 is not in the range [1, 10] [a b,c]
+	// missing option 'max'
+	// min must not be greater than max
+	// unknown option 'unknown'
+	// invalid option 'step': strconv.ParseInt: parsing "abc": invalid syntax
+}
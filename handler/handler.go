@@ -15,7 +15,11 @@
 // Package handler provides a handler interface.
 package handler
 
-import "reflect"
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
 
 // Handler is an interface to handle the struct field.
 type Handler interface {
@@ -23,6 +27,64 @@ type Handler interface {
 	Run(ctx any, rootStructValue, fieldValue reflect.Value, fieldType reflect.StructField, arg any) error
 }
 
+// The control-flow signals returned by the handler, which are honored
+// by the reflector instead of being regarded as the errors.
+//
+// They may be wrapped, and are checked by errors.Is.
+var (
+	// SkipChildren is used to skip reflecting the struct field recursively,
+	// but the rest handlers of the field still run.
+	SkipChildren = errors.New("skip children")
+
+	// SkipRemainingTags is used to skip the rest handlers of the struct field,
+	// but the field is still reflected recursively.
+	SkipRemainingTags = errors.New("skip remaining tags")
+
+	// StopAll is used to stop the whole reflection immediately,
+	// which returns nil or the errors having been collected.
+	StopAll = errors.New("stop all")
+)
+
+// FieldParser is an optional interface implemented by the handler,
+// which is used instead of Parse to pre-parse the tag value with the
+// struct field, such as converting the tag value into the field type.
+//
+// The parsed result is cached by the tag name, the tag value and the field
+// type, so it should only depend on them, not the field name.
+type FieldParser interface {
+	ParseField(fieldType reflect.StructField, tag string) (any, error)
+}
+
+// ParseField uses h to parse the tag value of the struct field sf,
+// which calls h.ParseField if h implements the interface FieldParser,
+// or h.Parse.
+func ParseField(h Handler, sf reflect.StructField, tag string) (any, error) {
+	if p, ok := h.(FieldParser); ok {
+		return p.ParseField(sf, tag)
+	}
+	return h.Parse(tag)
+}
+
+// Checker is an optional interface implemented by the handler,
+// which is used to check whether the struct field matches the tag
+// statically, such as whether the field type implements an interface.
+type Checker interface {
+	Check(fieldType reflect.StructField, arg any) error
+}
+
+// Describer is an optional interface implemented by the handler,
+// which describes the handler for the introspection, such as
+// Reflector.Lookup.
+type Describer interface {
+	Describe() Description
+}
+
+// Description is the description of the handler.
+type Description struct {
+	Summary string         // A short description of what the handler does.
+	Kinds   []reflect.Kind // The kinds of the fields supported by the handler, and empty is any.
+}
+
 // Parser is the function to pre-parse the field tag value.
 type Parser func(string) (any, error)
 
@@ -72,3 +134,77 @@ func (h handler) Parse(s string) (any, error) { return h.parse(s) }
 func (h handler) Run(c any, r, v reflect.Value, t reflect.StructField, a any) error {
 	return h.run(c, r, v, t, a)
 }
+
+// WithCheck returns a new Handler wrapping h with the check function,
+// which implements the interface Checker, and forwards the interface
+// FieldParser to h if h implements it.
+func WithCheck(h Handler, check func(reflect.StructField, any) error) Handler {
+	c := checker{Handler: h, check: check}
+	if _, ok := h.(FieldParser); ok {
+		return fieldChecker{c}
+	}
+	return c
+}
+
+type checker struct {
+	Handler
+	check func(reflect.StructField, any) error
+}
+
+func (h checker) Check(t reflect.StructField, a any) error { return h.check(t, a) }
+
+type fieldChecker struct{ checker }
+
+func (h fieldChecker) ParseField(t reflect.StructField, s string) (any, error) {
+	return h.Handler.(FieldParser).ParseField(t, s)
+}
+
+// PanicError represents the panic recovered by Recover.
+type PanicError struct {
+	Value any // The value passed to panic.
+}
+
+// Error implements the interface error.
+func (e PanicError) Error() string { return fmt.Sprintf("panic: %v", e.Value) }
+
+// Unwrap returns the panic value if it is an error. Or, return nil.
+func (e PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Recover returns a new Handler wrapping h, which recovers the panic
+// raised in h.Parse, h.ParseField or h.Run and returns it as a PanicError.
+//
+// It forwards the interface FieldParser to h only if h implements it.
+func Recover(h Handler) Handler {
+	if _, ok := h.(FieldParser); ok {
+		return fieldRecoverer{recoverer{h}}
+	}
+	return recoverer{h}
+}
+
+type recoverer struct{ Handler }
+
+func (h recoverer) Parse(s string) (arg any, err error) {
+	defer recoverError(&err)
+	return h.Handler.Parse(s)
+}
+
+type fieldRecoverer struct{ recoverer }
+
+func (h fieldRecoverer) ParseField(t reflect.StructField, s string) (arg any, err error) {
+	defer recoverError(&err)
+	return h.Handler.(FieldParser).ParseField(t, s)
+}
+
+func (h recoverer) Run(c any, r, v reflect.Value, t reflect.StructField, a any) (err error) {
+	defer recoverError(&err)
+	return h.Handler.Run(c, r, v, t, a)
+}
+
+func recoverError(err *error) {
+	if r := recover(); r != nil {
+		*err = PanicError{Value: r}
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler_test
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/xgfone/go-structs/handler"
+	"github.com/xgfone/go-structs/handler/setdefault"
+)
+
+func ExampleWithCheck() {
+	check := func(reflect.StructField, any) error { return nil }
+	isFieldParser := func(h handler.Handler) bool {
+		_, ok := h.(handler.FieldParser)
+		return ok
+	}
+
+	// Only forward the interface FieldParser if the wrapped handler implements it.
+	runner := handler.SimpleRunner(func(reflect.Value, any) error { return nil })
+	fmt.Println(isFieldParser(handler.WithCheck(runner, check)))
+	fmt.Println(isFieldParser(handler.WithCheck(setdefault.SetDefaultHandler(), check)))
+	fmt.Println(isFieldParser(handler.Recover(runner)))
+	fmt.Println(isFieldParser(handler.Recover(setdefault.SetDefaultHandler())))
+
+	// Output:
+	// false
+	// true
+	// false
+	// true
+}
@@ -16,6 +16,7 @@
 package setdefault
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
@@ -37,8 +38,7 @@ var (
 )
 
 // SetDefaultRunnder returns a runner to set the default value
-// of the struct field if it is ZERO, which is registered into DefaultReflector
-// with the tag name "default" by default.
+// of the struct field if it is ZERO.
 //
 // For the type of the field, it only supports some base types as follow:
 //
@@ -79,7 +79,77 @@ func SetDefaultRunner() handler.Runner {
 	return setter.SetterRunner(setdefault)
 }
 
-func setdefault(_ interface{}, root, fieldptr reflect.Value, sf reflect.StructField, arg interface{}) error {
+// SetDefaultHandler returns a handler to set the default value of the struct
+// field if it is ZERO, which is the same as SetDefaultRunner, but converts
+// the tag value into the type of the field when parsing the tag by
+// implementing the interface handler.FieldParser. So the invalid default
+// value is reported before running by Reflector.Check, by implementing
+// the interface handler.Checker. And, the same as SetDefaultRunner,
+// it is returned when running only if the field is ZERO.
+//
+// The tag value starting with "." or like "now()" is still converted
+// when running, so is the field implementing interface{ Set(interface{}) error }.
+//
+// It is registered into DefaultReflector with the tag name "default" by default.
+func SetDefaultHandler() handler.Handler {
+	return defaultHandler{setter.SetterRunner(setvalue)}
+}
+
+type defaultHandler struct{ handler.Runner }
+
+var setterType = reflect.TypeFor[interface{ Set(interface{}) error }]()
+
+func (h defaultHandler) ParseField(sf reflect.StructField, s string) (any, error) {
+	if (len(s) > 0 && s[0] == '.') || (strings.HasPrefix(s, "now(") && strings.HasSuffix(s, ")")) {
+		return s, nil
+	}
+
+	t := sf.Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	v := reflect.New(t)
+	if v.Type().Implements(setterType) {
+		return s, nil
+	}
+
+	if err := setdefault(nil, reflect.Value{}, v, sf, s); err != nil {
+		return invalidValue{err}, nil
+	}
+	return v.Elem(), nil
+}
+
+func (h defaultHandler) Check(_ reflect.StructField, arg any) error {
+	if v, ok := arg.(invalidValue); ok {
+		return v.err
+	}
+	return nil
+}
+
+// invalidValue is the default value failing to be converted by ParseField,
+// whose error is deferred to be returned when running.
+type invalidValue struct{ err error }
+
+// setvalue sets the field to the default value converted by ParseField.
+func setvalue(c interface{}, root, fieldptr reflect.Value, sf reflect.StructField, arg interface{}) error {
+	switch value := arg.(type) {
+	case reflect.Value:
+		if v := fieldptr.Elem(); v.IsZero() {
+			v.Set(value)
+		}
+		return nil
+
+	case invalidValue:
+		if fieldptr.Elem().IsZero() {
+			return value.err
+		}
+		return nil
+	}
+	return setdefault(c, root, fieldptr, sf, arg)
+}
+
+func setdefault(_ interface{}, root, fieldptr reflect.Value, _ reflect.StructField, arg interface{}) error {
 	v := fieldptr.Elem()
 	if !v.IsZero() {
 		return nil
@@ -88,7 +158,7 @@ func setdefault(_ interface{}, root, fieldptr reflect.Value, sf reflect.StructFi
 	s := arg.(string)
 	if len(s) > 0 && s[0] == '.' {
 		if s = s[1:]; s == "" {
-			return fmt.Errorf("%s: invalid default value", sf.Name)
+			return errors.New("invalid default value")
 		}
 
 		fieldv, ok := field.GetValueByName(root, s)
@@ -126,7 +196,7 @@ func setdefault(_ interface{}, root, fieldptr reflect.Value, sf reflect.StructFi
 		v.SetBool(i)
 
 	case reflect.Float32, reflect.Float64:
-		i, err := strconv.ParseFloat(s, 64)
+		i, err := strconv.ParseFloat(s, v.Type().Bits())
 		if err != nil {
 			return err
 		}
@@ -151,14 +221,14 @@ func setdefault(_ interface{}, root, fieldptr reflect.Value, sf reflect.StructFi
 		v.SetInt(i)
 
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
-		i, err := strconv.ParseInt(s, 10, 64)
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
 		if err != nil {
 			return err
 		}
 		v.SetInt(i)
 
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		i, err := strconv.ParseUint(s, 10, 64)
+		i, err := strconv.ParseUint(s, 10, v.Type().Bits())
 		if err != nil {
 			return err
 		}
@@ -166,7 +236,7 @@ func setdefault(_ interface{}, root, fieldptr reflect.Value, sf reflect.StructFi
 
 	case reflect.Struct:
 		if _, ok := v.Interface().(time.Time); !ok {
-			return fmt.Errorf("%s: unsupported type %T", sf.Name, v.Interface())
+			return fmt.Errorf("unsupported type %T", v.Interface())
 		}
 
 		i, err := ParseTime(s)
@@ -176,7 +246,7 @@ func setdefault(_ interface{}, root, fieldptr reflect.Value, sf reflect.StructFi
 		v.Set(reflect.ValueOf(i))
 
 	default:
-		return fmt.Errorf("%s: unsupported type %T", sf.Name, v.Interface())
+		return fmt.Errorf("unsupported type %T", v.Interface())
 	}
 
 	return nil
@@ -16,6 +16,7 @@ package setdefault_test
 
 import (
 	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/xgfone/go-defaults"
@@ -152,3 +153,47 @@ func ExampleSetDefaultRunner() {
 	// 2022-07-24T22:56:29Z
 	// 3s
 }
+
+func ExampleSetDefaultHandler() {
+	sf := structs.NewReflector()
+	sf.Register("default", setdefault.SetDefaultHandler())
+
+	type S struct {
+		Int8     int8          `default:"10"`
+		Duration time.Duration `default:"10"`
+		Invalid  int8          `default:"1000"`
+	}
+
+	fmt.Println(sf.Check(reflect.TypeOf(S{})))
+
+	type T struct {
+		Int8     int8          `default:"10"`
+		Duration time.Duration `default:"10"`
+	}
+
+	var t T
+	fmt.Println(sf.Reflect(&t), t.Int8, t.Duration)
+
+	// Output:
+	// setdefault_test.S.Invalid: invalid tag 'default' value '1000': strconv.ParseInt: parsing "1000": value out of range
+	// <nil> 10 10ms
+}
+
+func ExampleSetDefaultHandler_invalid() {
+	type S struct {
+		Int    int   `default:"abc"`
+		Slice1 []int `default:"1"`
+		Slice2 []int `default:"1"`
+	}
+
+	// The invalid default value does not panic,
+	// and is returned only if the field is ZERO.
+	fmt.Println(structs.Reflect(&S{Int: 5, Slice1: []int{1}, Slice2: []int{2}}))
+	fmt.Println(structs.Reflect(&S{Slice1: []int{1}, Slice2: []int{2}}))
+	fmt.Println(structs.Reflect(&S{Int: 5, Slice1: []int{1}}))
+
+	// Output:
+	// <nil>
+	// Int: strconv.ParseInt: parsing "abc": invalid syntax
+	// Slice2: unsupported type []int
+}
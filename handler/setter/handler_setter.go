@@ -41,6 +41,37 @@ func SetFormatRunner() handler.Runner {
 	}))
 }
 
+var (
+	setFormatType  = reflect.TypeFor[interface{ SetFormat(string) }]()
+	setFormatType2 = reflect.TypeFor[interface{ SetFormat(string) error }]()
+	setterType     = reflect.TypeFor[interface{ Set(any) error }]()
+)
+
+// CheckSetFormat checks whether the struct field can be set by the runner
+// returned by SetFormatRunner, which is used by handler.WithCheck.
+func CheckSetFormat(sf reflect.StructField, _ any) error {
+	if t := pointerType(sf.Type); !t.Implements(setFormatType) && !t.Implements(setFormatType2) {
+		return fmt.Errorf("%s has not implemented the interface { SetFormat(string) } or { SetFormat(string) error }", t)
+	}
+	return nil
+}
+
+// CheckSetter checks whether the struct field can be set by the runner
+// returned by SetterRunner(nil), which is used by handler.WithCheck.
+func CheckSetter(sf reflect.StructField, _ any) error {
+	if t := pointerType(sf.Type); !t.Implements(setterType) {
+		return fmt.Errorf("%s has not implemented the interface setter.Setter", t)
+	}
+	return nil
+}
+
+func pointerType(t reflect.Type) reflect.Type {
+	if t.Kind() != reflect.Pointer {
+		t = reflect.PointerTo(t)
+	}
+	return t
+}
+
 // SetterRunner returns a runner to set the struct field to something
 // by the set function.
 //
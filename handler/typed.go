@@ -0,0 +1,88 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Typed returns a new Handler only for the struct field of type F or *F,
+// which parses the tag value into the argument of type A by parse,
+// and runs run with the pointer to the field and the typed argument.
+//
+// If parse is nil, the tag value is used as the argument directly,
+// so A must be string or any.
+//
+// If the field is a nil *F, run is not called. If the field is neither F
+// nor *F, it returns an error, which is also reported by the interface
+// Checker statically.
+func Typed[F, A any](parse func(string) (A, error), run func(ctx any, field *F, arg A) error) Handler {
+	return typed[F, A]{parse: parse, run: run, ftype: reflect.TypeFor[F]()}
+}
+
+type typed[F, A any] struct {
+	parse func(string) (A, error)
+	run   func(ctx any, field *F, arg A) error
+	ftype reflect.Type
+}
+
+func (h typed[F, A]) Parse(s string) (any, error) {
+	if h.parse != nil {
+		return h.parse(s)
+	}
+
+	if arg, ok := any(s).(A); ok {
+		return arg, nil
+	}
+	return nil, fmt.Errorf("cannot use the tag value as %s", reflect.TypeFor[A]())
+}
+
+func (h typed[F, A]) Check(sf reflect.StructField, _ any) error {
+	switch {
+	case sf.Type == h.ftype:
+	case sf.Type.Kind() == reflect.Pointer && sf.Type.Elem() == h.ftype:
+	default:
+		return h.mismatch(sf.Type)
+	}
+	return nil
+}
+
+func (h typed[F, A]) Run(c any, _, v reflect.Value, sf reflect.StructField, arg any) error {
+	var field *F
+	switch {
+	case v.Type() == h.ftype:
+		if !v.CanAddr() {
+			return fmt.Errorf("the field '%s' is not addressable", sf.Name)
+		}
+		field = v.Addr().Interface().(*F)
+
+	case v.Kind() == reflect.Pointer && v.Type().Elem() == h.ftype:
+		if v.IsNil() {
+			return nil
+		}
+		field = v.Interface().(*F)
+
+	default:
+		return h.mismatch(v.Type())
+	}
+
+	_arg, _ := arg.(A) // arg may be nil if A is an interface.
+	return h.run(c, field, _arg)
+}
+
+func (h typed[F, A]) mismatch(t reflect.Type) error {
+	return fmt.Errorf("expect the field of type %s or *%s, but got %s", h.ftype, h.ftype, t)
+}
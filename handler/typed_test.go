@@ -0,0 +1,55 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler_test
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/xgfone/go-structs"
+	"github.com/xgfone/go-structs/handler"
+)
+
+func ExampleTyped() {
+	sf := structs.NewReflector()
+	sf.Register("timeout", handler.Typed(time.ParseDuration,
+		func(_ any, field *time.Duration, max time.Duration) error {
+			if *field == 0 || *field > max {
+				*field = max
+			}
+			return nil
+		}))
+
+	type Config struct {
+		Read  time.Duration  `timeout:"3s"`
+		Write *time.Duration `timeout:"5s"`
+	}
+
+	write := 10 * time.Second
+	c := Config{Write: &write}
+	fmt.Println(sf.Reflect(&c), c.Read, *c.Write)
+
+	type Invalid struct {
+		Read int `timeout:"3s"`
+	}
+	fmt.Println(sf.Check(reflect.TypeOf(Invalid{})))
+	fmt.Println(sf.Reflect(&Invalid{}))
+
+	// Output:
+	// <nil> 3s 5s
+	// handler_test.Invalid.Read: invalid tag 'timeout' value '3s': expect the field of type time.Duration or *time.Duration, but got int
+	// Read: expect the field of type time.Duration or *time.Duration, but got int
+}
@@ -16,7 +16,6 @@
 package validate
 
 import (
-	"fmt"
 	"reflect"
 
 	"github.com/xgfone/go-defaults"
@@ -29,25 +28,16 @@ import (
 // into DefaultReflector with the tag name "validate" by default.
 //
 // If ruleValidator is nil, use defaults.RuleValidator instead.
+//
+// The returned error does not contain the field name, because the reflector
+// wraps it as a structs.FieldError with the path of the field, whose message
+// uses the names returned by defaults.GetStructFieldName, such as the JSON
+// names, like "persons[1].user_name: ...".
 func ValidateStructFieldRunner(ruleValidator assists.RuleValidator) handler.Runner {
-	return handler.FieldRunner(func(v reflect.Value, sf reflect.StructField, a any) (err error) {
+	return handler.SimpleRunner(func(v reflect.Value, a any) error {
 		if ruleValidator == nil {
-			err = defaults.ValidateWithRule(v.Interface(), a.(string))
-		} else {
-			err = ruleValidator.Validate(v.Interface(), a.(string))
-		}
-
-		if err != nil {
-			err = fmt.Errorf("%s: %w", getStructFieldName(sf), err)
+			return defaults.ValidateWithRule(v.Interface(), a.(string))
 		}
-		return
+		return ruleValidator.Validate(v.Interface(), a.(string))
 	})
 }
-
-func getStructFieldName(sf reflect.StructField) (name string) {
-	name, _ = defaults.GetStructFieldName(sf)
-	if name == "" {
-		name = sf.Name
-	}
-	return
-}
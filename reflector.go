@@ -18,9 +18,9 @@ package structs
 
 import (
 	"fmt"
+	"maps"
 	"reflect"
 	"strconv"
-	"strings"
 	"sync"
 	"sync/atomic"
 
@@ -30,14 +30,14 @@ import (
 // DefaultReflector is the default global struct field reflector.
 var DefaultReflector = NewReflector()
 
-// Register is equal to DefaultReflector.Register(name, handler).
-func Register(name string, handler handler.Handler) {
-	DefaultReflector.Register(name, handler)
+// Register is equal to DefaultReflector.Register(name, handler, options...).
+func Register(name string, handler handler.Handler, options ...RegisterOption) {
+	DefaultReflector.Register(name, handler, options...)
 }
 
-// RegisterRunner is equal to Register(name, handler).
-func RegisterRunner(name string, handler handler.Runner) {
-	DefaultReflector.Register(name, handler)
+// RegisterRunner is equal to Register(name, handler, options...).
+func RegisterRunner(name string, handler handler.Runner, options ...RegisterOption) {
+	DefaultReflector.Register(name, handler, options...)
 }
 
 // Unregister is equal to DefaultReflector.Unregister(name).
@@ -45,6 +45,16 @@ func Unregister(name string) {
 	DefaultReflector.Unregister(name)
 }
 
+// RegisterType is equal to DefaultReflector.RegisterType(t, handler, options...).
+func RegisterType(t reflect.Type, handler handler.Handler, options ...RegisterOption) {
+	DefaultReflector.RegisterType(t, handler, options...)
+}
+
+// UnregisterType is equal to DefaultReflector.UnregisterType(t).
+func UnregisterType(t reflect.Type) {
+	DefaultReflector.UnregisterType(t)
+}
+
 // Reflect is equal to ReflectContext(nil, structValuePtr).
 func Reflect(structValuePtr any) error {
 	return DefaultReflector.ReflectContext(nil, structValuePtr)
@@ -65,9 +75,33 @@ func ReflectValueContext(ctx any, structValue reflect.Value) error {
 	return DefaultReflector.ReflectValueContext(ctx, structValue)
 }
 
+// ReflectAll is equal to DefaultReflector.ReflectAll(ctx, structValuePtr).
+func ReflectAll(ctx, structValuePtr any) error {
+	return DefaultReflector.ReflectAll(ctx, structValuePtr)
+}
+
+// ReflectOnly is equal to DefaultReflector.ReflectOnly(ctx, structValuePtr, names...).
+func ReflectOnly(ctx, structValuePtr any, names ...string) error {
+	return DefaultReflector.ReflectOnly(ctx, structValuePtr, names...)
+}
+
+// ReflectExcept is equal to DefaultReflector.ReflectExcept(ctx, structValuePtr, names...).
+func ReflectExcept(ctx, structValuePtr any, names ...string) error {
+	return DefaultReflector.ReflectExcept(ctx, structValuePtr, names...)
+}
+
 type tagKey struct {
 	Name  string
 	Value string
+	Type  reflect.Type // Only for the type handler or the handler implementing handler.FieldParser.
+}
+
+func newTagKey(h handler.Handler, name string, sf reflect.StructField, qvalue string) tagKey {
+	key := tagKey{Name: name, Value: qvalue}
+	if _, ok := h.(handler.FieldParser); ok || isTypeHandlerName(name) {
+		key.Type = sf.Type
+	}
+	return key
 }
 
 type tagValue struct {
@@ -77,32 +111,290 @@ type tagValue struct {
 
 // Reflector is used to reflect the tags of the fields of the struct
 // and call the field handler by the tag name with the tag value.
+//
+// The tags of a struct type are parsed only once, which are compiled
+// into an execution plan and replayed for the subsequent reflections.
+//
+// It is safe to register or unregister the handlers at any time,
+// even if other goroutines are reflecting concurrently.
 type Reflector struct {
-	handlers map[string]handler.Handler
+	handlers   atomic.Value
+	handlerMap map[string]handlerEntry
+	frozen     atomic.Bool
+
+	typeHandlers   atomic.Value
+	typeHandlerMap map[reflect.Type]handlerEntry
+
+	tagOverlays   atomic.Value
+	tagOverlayMap map[reflect.Type]map[string]overlayTag
 
 	tagCache  atomic.Value
 	cacheMap  map[tagKey]tagValue
 	cacheLock sync.Mutex
+
+	// planLock is also used to protect handlerMap, typeHandlerMap
+	// and tagOverlayMap, and must be locked before cacheLock.
+	planCache atomic.Value
+	planMap   planSet
+	planLock  sync.Mutex
+
+	middlewares atomic.Value // []Middleware, updated with planLock locked.
+
+	config
+}
+
+// config is the configuration of the Reflector set by the options.
+type config struct {
+	collectAll bool
+	noMaps     bool
+	noIfaces   bool
+	cycleErr   bool
+	allocNil   bool
+	configErr  bool
+	tagOrder   bool
+	phases     []string
+	tracer     Tracer
+}
+
+// Option is used to configure the Reflector.
+type Option func(*Reflector)
+
+// CollectAllErrors returns an option to decide whether to continue
+// to reflect the rest fields when a handler fails, and to return all
+// the errors as a MultiError, even if only one error occurs.
+//
+// Default: false, that's, return the first error.
+func CollectAllErrors(all bool) Option {
+	return func(r *Reflector) { r.collectAll = all }
+}
+
+// ReflectMapValues returns an option to decide whether to reflect
+// the values of the map field recursively, whose values are structs
+// or pointers to structs.
+//
+// For the struct value, it is copied to be reflected, then is set back
+// into the map only if any handler has run on it. So the map, whose value
+// type has no fields to be handled, is never written.
+//
+// Default: true
+func ReflectMapValues(reflect bool) Option {
+	return func(r *Reflector) { r.noMaps = !reflect }
+}
+
+// ReflectInterfaces returns an option to decide whether to reflect
+// the interface field recursively, which holds a pointer to struct.
+//
+// Default: true
+func ReflectInterfaces(reflect bool) Option {
+	return func(r *Reflector) { r.noIfaces = !reflect }
+}
+
+// ReturnCycleError returns an option to decide whether to return
+// a FieldError wrapping ErrCycle when a pointer references its ancestor.
+//
+// A pointer is visited only once during a reflection call, so the cyclic
+// pointer graph never leads to an infinite recursion. If disabled,
+// the cyclic pointer is skipped silently.
+//
+// Default: false
+func ReturnCycleError(ret bool) Option {
+	return func(r *Reflector) { r.cycleErr = ret }
+}
+
+// AllocNilStructs returns an option to decide whether to allocate
+// the nil pointer to struct field before reflecting it recursively,
+// if the struct or its nested structs have the fields to be handled.
+//
+// It can be overridden by the field tag `reflect:"alloc"` to allocate
+// or `reflect:"noalloc"` to not allocate for a single field.
+//
+// Default: false
+func AllocNilStructs(alloc bool) Option {
+	return func(r *Reflector) { r.allocNil = alloc }
+}
+
+// ReturnConfigErrors returns an option to decide whether to return
+// the configuration errors as *ConfigError instead of panicking,
+// such as failing to parse the tag value, or that the handler panics
+// because the field does not match the tag.
+//
+// If enabled, the panic raised in the handler is recovered by handler.Recover
+// and regarded as a configuration error.
+//
+// Default: false
+func ReturnConfigErrors(ret bool) Option {
+	return func(r *Reflector) { r.configErr = ret }
+}
+
+// KeepTagOrder returns an option to decide whether to run the handlers
+// of a struct field in the order of the tags, instead of their priorities.
+//
+// Default: false
+func KeepTagOrder(keep bool) Option {
+	return func(r *Reflector) { r.tagOrder = keep }
+}
+
+// Phases returns an option to set the phases, in each of which
+// all the fields of the whole struct are reflected recursively
+// and only the handlers belonging to the phase run.
+// So the handlers of a phase run only after the handlers of all
+// the previous phases have finished for the whole struct.
+//
+// The phase of the handler is set by the register option WithPhase.
+// The handler whose phase is not in the list runs in the phase ""
+// if it is in the list, or the last phase.
+//
+// Default: no phases, that's, reflect the struct only once
+// and run all the handlers.
+//
+// Example:
+//
+//	NewReflector(Phases(PhaseNormalize, PhaseDefault, PhaseTransform, PhaseValidate))
+func Phases(phases ...string) Option {
+	return func(r *Reflector) { r.phases = phases }
 }
 
-// NewReflector returns a new Reflector.
-func NewReflector() *Reflector {
+// WithTracer returns an option to set the tracer, which is called
+// after each handler runs on a struct field, such as Metrics.
+//
+// Default: nil
+func WithTracer(tracer Tracer) Option {
+	return func(r *Reflector) { r.tracer = tracer }
+}
+
+// NewReflector returns a new Reflector with the options.
+func NewReflector(options ...Option) *Reflector {
 	r := &Reflector{
-		handlers: make(map[string]handler.Handler, 8),
-		cacheMap: make(map[tagKey]tagValue, 32),
+		handlerMap:     make(map[string]handlerEntry, 8),
+		typeHandlerMap: make(map[reflect.Type]handlerEntry),
+		tagOverlayMap:  make(map[reflect.Type]map[string]overlayTag),
+		cacheMap:       make(map[tagKey]tagValue, 32),
+		planMap: planSet{
+			plans: make(map[reflect.Type]*structPlan, 16),
+			fulls: make(map[reflect.Type]*structPlan),
+		},
+	}
+	return r.init(options)
+}
+
+// NewChild returns a new child reflector, which inherits the handlers
+// and the options of the parent, then applies the extra options.
+//
+// The child is a snapshot of the parent, so registering or unregistering
+// the handlers of the child does not affect the parent, and vice versa.
+// And the child is not frozen even if the parent has been frozen.
+//
+// Unlike Clone, the child does not inherit the caches of the parent.
+func NewChild(parent *Reflector, options ...Option) *Reflector {
+	return parent.clone(false, options)
+}
+
+// Clone returns a copy of the reflector, which is the same as NewChild(r)
+// but also inherits the cached tag arguments and compiled plans.
+func (r *Reflector) Clone() *Reflector {
+	return r.clone(true, nil)
+}
+
+func (r *Reflector) clone(caches bool, options []Option) *Reflector {
+	r.planLock.Lock()
+	defer r.planLock.Unlock()
+
+	c := &Reflector{
+		config:         r.config,
+		handlerMap:     maps.Clone(r.handlerMap),
+		typeHandlerMap: maps.Clone(r.typeHandlerMap),
+		tagOverlayMap:  maps.Clone(r.tagOverlayMap),
+	}
+	c.middlewares.Store(r.loadMiddlewares())
+	if caches {
+		r.cacheLock.Lock()
+		c.cacheMap = maps.Clone(r.cacheMap)
+		r.cacheLock.Unlock()
+
+		c.planMap = planSet{
+			plans: maps.Clone(r.planMap.plans),
+			fulls: maps.Clone(r.planMap.fulls),
+		}
+	} else {
+		c.cacheMap = make(map[tagKey]tagValue, 32)
+		c.planMap = planSet{
+			plans: make(map[reflect.Type]*structPlan, 16),
+			fulls: make(map[reflect.Type]*structPlan),
+		}
 	}
+	return c.init(options)
+}
+
+func (r *Reflector) init(options []Option) *Reflector {
+	for _, option := range options {
+		option(r)
+	}
+	r.updateHandlers()
+	r.updateTypeHandlers()
+	r.updateTagOverlays()
 	r.updateTags()
+	r.updatePlans()
 	return r
 }
 
+// Freeze freezes the reflector, after which registering or unregistering
+// the handler, or using the middlewares, will panic.
+func (r *Reflector) Freeze() { r.frozen.Store(true) }
+
+// Frozen reports whether the reflector has been frozen.
+func (r *Reflector) Frozen() bool { return r.frozen.Load() }
+
 // Register registers the field handler with the tag name.
-func (r *Reflector) Register(name string, handler handler.Handler) {
-	r.handlers[name] = handler
+//
+// For a struct field, the handlers run in the order of their priorities,
+// which can be set by the option WithPriority, unless the option KeepTagOrder
+// is enabled. And the phase of the handler can be set by the option WithPhase,
+// see the option Phases.
+//
+// If the reflector has been frozen, it panics.
+func (r *Reflector) Register(name string, handler handler.Handler, options ...RegisterOption) {
+	r.updateHandler(name, handler, options)
 }
 
 // Unregister unregisters the field handler by the tag name.
+//
+// If the reflector has been frozen, it panics.
 func (r *Reflector) Unregister(name string) {
-	delete(r.handlers, name)
+	r.updateHandler(name, nil, nil)
+}
+
+// updateHandler registers the handler h with the name,
+// or unregisters it if h is nil.
+func (r *Reflector) updateHandler(name string, h handler.Handler, options []RegisterOption) {
+	r.planLock.Lock()
+	defer r.planLock.Unlock()
+
+	if r.frozen.Load() {
+		panic(fmt.Errorf("the reflector has been frozen, and cannot update the handler '%s'", name))
+	}
+
+	if h == nil {
+		delete(r.handlerMap, name)
+	} else {
+		prev, exist := r.handlerMap[name]
+		r.handlerMap[name] = newHandlerEntry(h, prev, exist, options)
+	}
+
+	r.updateHandlers()
+	r.resetCaches(name)
+}
+
+func (r *Reflector) updateHandlers() {
+	handlers := make(map[string]handlerEntry, len(r.handlerMap))
+	for name, entry := range r.handlerMap {
+		handlers[name] = entry
+	}
+	r.handlers.Store(handlers)
+}
+
+func (r *Reflector) loadHandler(name string) (e handlerEntry, ok bool) {
+	e, ok = r.handlers.Load().(map[string]handlerEntry)[name]
+	return
 }
 
 // Reflect is equal to ReflectContext(nil, structValuePtr).
@@ -117,9 +409,24 @@ func (r *Reflector) ReflectValue(value reflect.Value) error {
 
 // ReflectContext reflects all the fields of the struct.
 //
-// If the field is a struct or slice/array of structs,
-// and has a tag named "reflect" with the value "-",
-// it stops to reflect the struct field recursively.
+// If the field is a struct, a pointer to struct, a slice/array of structs,
+// a map of structs or pointers to structs, or an interface holding
+// a pointer to struct, it will be reflected recursively. But if it has
+// a tag named "reflect" with the value "-", it stops to reflect the struct
+// field recursively.
+//
+// The value of the tag "reflect" is a list of options separated by the comma:
+//
+//	"-"        Stop to reflect the field recursively.
+//	"alloc"    Allocate the nil pointer to struct, see AllocNilStructs.
+//	"noalloc"  Not allocate the nil pointer to struct, see AllocNilStructs.
+//	"notype"   Not run the type handlers on the field, see RegisterType.
+//
+// The handler may return a control-flow signal to change the reflection
+// at runtime, which is not regarded as an error, see handler.SkipChildren,
+// handler.SkipRemainingTags and handler.StopAll. With the option Phases,
+// handler.SkipChildren and handler.SkipRemainingTags only take effect
+// in the current phase.
 func (r *Reflector) ReflectContext(ctx, structValuePtr any) error {
 	if structValuePtr == nil {
 		return nil
@@ -127,9 +434,61 @@ func (r *Reflector) ReflectContext(ctx, structValuePtr any) error {
 	return r.ReflectValueContext(ctx, reflect.ValueOf(structValuePtr))
 }
 
+// ReflectAll is the same as ReflectContext, but always continues
+// to reflect the rest fields when a handler fails and returns all
+// the errors as a MultiError, even if only one error occurs or
+// the option CollectAllErrors is not enabled.
+func (r *Reflector) ReflectAll(ctx, structValuePtr any) error {
+	if structValuePtr == nil {
+		return nil
+	}
+	return r.reflectValue(ctx, reflect.ValueOf(structValuePtr), callOptions{all: true})
+}
+
+// ReflectOnly is the same as ReflectContext, but only runs the handlers
+// registered with the given tag names.
+func (r *Reflector) ReflectOnly(ctx, structValuePtr any, names ...string) error {
+	if structValuePtr == nil {
+		return nil
+	}
+
+	if names == nil {
+		names = []string{}
+	}
+	opts := callOptions{all: r.collectAll, names: names}
+	return r.reflectValue(ctx, reflect.ValueOf(structValuePtr), opts)
+}
+
+// ReflectExcept is the same as ReflectContext, but does not run
+// the handlers registered with the given tag names.
+func (r *Reflector) ReflectExcept(ctx, structValuePtr any, names ...string) error {
+	if structValuePtr == nil {
+		return nil
+	}
+
+	opts := callOptions{all: r.collectAll, names: names, except: true}
+	return r.reflectValue(ctx, reflect.ValueOf(structValuePtr), opts)
+}
+
 // ReflectValueContext is the same as ReflectContext,
 // but uses reflect.Value instead of a pointer to a struct.
 func (r *Reflector) ReflectValueContext(ctx any, value reflect.Value) error {
+	return r.reflectValue(ctx, value, callOptions{all: r.collectAll})
+}
+
+// callOptions is the options of a single reflection call.
+type callOptions struct {
+	all bool // Collect all the errors instead of returning the first.
+
+	// If names is not nil, only run the handlers with the tag names,
+	// or not run them if except is true.
+	names  []string
+	except bool
+
+	overlay *Overlay
+}
+
+func (r *Reflector) reflectValue(ctx any, value reflect.Value, opts callOptions) error {
 	switch kind := value.Kind(); kind {
 	case reflect.Struct:
 	case reflect.Pointer:
@@ -137,58 +496,50 @@ func (r *Reflector) ReflectValueContext(ctx any, value reflect.Value) error {
 			return nil
 		}
 
-		elem := value.Elem()
-		if elem.Kind() != reflect.Struct {
+		if value.Elem().Kind() != reflect.Struct {
 			return fmt.Errorf("the value %T is not a pointer to struct", value.Interface())
 		}
-		value = elem
 	default:
 		return fmt.Errorf("the value %T is not a struct", value.Interface())
 	}
 
-	return r.reflectStruct(ctx, value, value)
-}
-
-func (r *Reflector) reflectStruct(ctx any, root, v reflect.Value) (err error) {
-	t := v.Type()
-	for i, _len := 0, v.NumField(); i < _len; i++ {
-		if err = r.reflectField(ctx, root, v.Field(i), t.Field(i)); err != nil {
-			return err
-		}
+	t := value.Type()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
 	}
-	return
-}
 
-func (r *Reflector) reflectField(ctx any, root, v reflect.Value, t reflect.StructField) (err error) {
-	if !t.IsExported() {
-		return
+	// Fast path: nothing to do for the struct without fields to be handled.
+	if t.NumField() == 0 {
+		return nil
 	}
+	plan := r.getFullPlan(t, opts.overlay != nil)
+	if len(plan.fields) == 0 {
+		return nil
+	}
+
+	w := getWalker(r, ctx)
+	defer putWalker(w)
+
+	w.callOptions = opts
+	return w.walk(value, plan)
+}
 
-	stop, err := r.walkTag(ctx, root, v, t, string(t.Tag))
-	if err == nil && !stop {
-		switch v.Kind() {
-		case reflect.Struct:
-			err = r.reflectStruct(ctx, root, v)
-
-		case reflect.Pointer:
-			if !v.IsNil() {
-				if v = v.Elem(); v.Kind() == reflect.Struct {
-					err = r.reflectStruct(ctx, root, v)
-				}
-			}
-
-		case reflect.Array, reflect.Slice:
-			for i, _len := 0, v.Len(); i < _len; i++ {
-				if vf := v.Index(i); vf.Kind() == reflect.Struct {
-					if err = r.reflectStruct(ctx, root, vf); err != nil {
-						break
-					}
-				}
-			}
+// resetCaches clears the compiled plans and the cached tag arguments
+// of the handler named name, or all the handlers if name is empty,
+// which must be called with planLock locked.
+func (r *Reflector) resetCaches(name string) {
+	r.cacheLock.Lock()
+	for key := range r.cacheMap {
+		if name == "" || key.Name == name {
+			delete(r.cacheMap, key)
 		}
 	}
+	r.updateTags()
+	r.cacheLock.Unlock()
 
-	return
+	clear(r.planMap.plans)
+	clear(r.planMap.fulls)
+	r.updatePlans()
 }
 
 func (r *Reflector) updateTags() {
@@ -204,102 +555,45 @@ func (r *Reflector) loadTags(key tagKey) (value tagValue, ok bool) {
 	return
 }
 
-func (r *Reflector) getTagArg(handler handler.Handler, name, qvalue string) tagValue {
-	key := tagKey{Name: name, Value: qvalue}
+// getTagArg returns the parsed argument of the tag value of the struct
+// field sf, which is cached.
+//
+// If failing to parse the tag value, the returned value is the unquoted
+// tag value if possible, or the original quoted.
+func (r *Reflector) getTagArg(h handler.Handler, name string, sf reflect.StructField, qvalue string) (tagValue, error) {
+	key := newTagKey(h, name, sf, qvalue)
 	if tvalue, ok := r.loadTags(key); ok {
-		return tvalue
+		return tvalue, nil
 	}
 
 	r.cacheLock.Lock()
 	defer r.cacheLock.Unlock()
 
 	if tvalue, ok := r.loadTags(key); ok {
-		return tvalue
+		return tvalue, nil
 	}
 
-	value, err := strconv.Unquote(qvalue)
+	tvalue, err := parseTagArg(h, sf, qvalue)
 	if err != nil {
-		panic(fmt.Errorf("invalid tag '%s' value: %s", name, err))
+		return tvalue, err
 	}
 
-	arg, err := handler.Parse(value)
-	if err != nil {
-		panic(fmt.Errorf("invalid tag '%s' value '%s': %s", name, value, err))
-	}
-
-	tvalue := tagValue{Value: qvalue, Arg: arg}
 	r.cacheMap[key] = tvalue
 	r.updateTags()
 
-	return tvalue
-}
-
-func unquote(s string) string {
-	if _s, err := strconv.Unquote(s); err == nil {
-		return strings.TrimSpace(_s)
-	}
-	return s
+	return tvalue, nil
 }
 
-func (r *Reflector) do(ctx any, root, v reflect.Value, t reflect.StructField, name, value string, stop *bool) (err error) {
-	if name == "reflect" && (value == `"-"` || unquote(value) == "-") {
-		*stop = true
-		return
-	}
-
-	if h, ok := r.handlers[name]; ok {
-		err = h.Run(ctx, root, v, t, r.getTagArg(h, name, value).Arg)
+func parseTagArg(h handler.Handler, sf reflect.StructField, qvalue string) (tagValue, error) {
+	value, err := strconv.Unquote(qvalue)
+	if err != nil {
+		return tagValue{Value: qvalue}, err
 	}
 
-	return
-}
-
-// copy and modify from https://github.com/golang/go/blob/go1.18.4/src/reflect/type.go
-func (r *Reflector) walkTag(ctx any, root, v reflect.Value, t reflect.StructField, tag string) (stop bool, err error) {
-	for tag != "" {
-		// Skip leading space.
-		i := 0
-		for i < len(tag) && tag[i] == ' ' {
-			i++
-		}
-		tag = tag[i:]
-		if tag == "" {
-			break
-		}
-
-		// Scan to colon. A space, a quote or a control character is a syntax error.
-		// Strictly speaking, control chars include the range [0x7f, 0x9f], not just
-		// [0x00, 0x1f], but in practice, we ignore the multi-byte control characters
-		// as it is simpler to inspect the tag's bytes than the tag's runes.
-		i = 0
-		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
-			i++
-		}
-		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
-			break
-		}
-		name := string(tag[:i])
-		tag = tag[i+1:]
-
-		// Scan quoted string to find value.
-		i = 1
-		for i < len(tag) && tag[i] != '"' {
-			if tag[i] == '\\' {
-				i++
-			}
-			i++
-		}
-		if i >= len(tag) {
-			break
-		}
-		qvalue := string(tag[:i+1])
-		tag = tag[i+1:]
-
-		// (xgfone): Poll the key-value tag.
-		if err = r.do(ctx, root, v, t, name, qvalue, &stop); err != nil {
-			break
-		}
+	arg, err := handler.ParseField(h, sf, value)
+	if err != nil {
+		return tagValue{Value: value}, err
 	}
 
-	return
+	return tagValue{Value: value, Arg: arg}, nil
 }
@@ -67,3 +67,53 @@ func BenchmarkReflector_Field2(b *testing.B) {
 		}
 	})
 }
+
+func BenchmarkReflector_Nested(b *testing.B) {
+	sf := NewReflector()
+	sf.Register("noop", handler.SimpleRunner(func(reflect.Value, interface{}) error { return nil }))
+	type Item struct {
+		F1 int    `json:"f1" noop:"noop"`
+		F2 string `json:"f2" noop:"noop"`
+		F3 int    `json:"f3"`
+	}
+	type S struct {
+		F1    int    `json:"f1" noop:"noop"`
+		F2    string `json:"f2"`
+		Item  Item
+		Items []Item
+	}
+	items := make([]Item, 4)
+
+	b.ResetTimer()
+	b.RunParallel(func(p *testing.PB) {
+		for p.Next() {
+			s := S{Items: items}
+			_ = sf.Reflect(&s)
+		}
+	})
+}
+
+func BenchmarkReflector_Unhandled(b *testing.B) {
+	sf := NewReflector()
+	sf.Register("noop", handler.SimpleRunner(func(reflect.Value, interface{}) error { return nil }))
+	type Item struct {
+		F1 int    `json:"f1"`
+		F2 string `json:"f2"`
+	}
+	type S struct {
+		F1    int `json:"f1" noop:"noop"`
+		Item  Item
+		Items []Item
+		Map   map[string]Item
+	}
+	items := make([]Item, 4)
+	m := map[string]Item{"a": {}, "b": {}}
+
+	b.ResetTimer()
+	b.RunParallel(func(p *testing.PB) {
+		for p.Next() {
+			s := S{Items: items, Map: m}
+			_ = sf.Reflect(&s)
+		}
+	})
+}
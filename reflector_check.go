@@ -0,0 +1,112 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structs
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/xgfone/go-structs/handler"
+)
+
+// Check is equal to DefaultReflector.Check(reflect.TypeFor[T]()).
+func Check[T any]() error {
+	return DefaultReflector.Check(reflect.TypeFor[T]())
+}
+
+// Check checks the struct types statically, and returns all the problems
+// as the *ConfigError, which is used to fail at the startup instead of
+// at the first reflection.
+//
+// It parses all the registered tags of the struct fields recursively,
+// which also warms the cache, and calls the handler to check whether
+// the struct field matches the tag if it implements the interface
+// handler.Checker.
+//
+// The type must be a struct or a pointer to struct.
+func (r *Reflector) Check(types ...reflect.Type) error {
+	var errs []error
+	seen := make(map[reflect.Type]struct{}, 8)
+	for _, t := range types {
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		if t.Kind() != reflect.Struct {
+			errs = append(errs, fmt.Errorf("the type %s is not a struct", t))
+		} else {
+			errs = r.checkStruct(t, seen, errs)
+		}
+	}
+
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		return MultiError(errs)
+	}
+}
+
+func (r *Reflector) checkStruct(t reflect.Type, seen map[reflect.Type]struct{}, errs []error) []error {
+	if _, ok := seen[t]; ok {
+		return errs
+	}
+	seen[t] = struct{}{}
+
+	plan := r.getPlan(t)
+	for i := range plan.fields {
+		f := &plan.fields[i]
+		for j := range f.tags {
+			tp := &f.tags[j]
+			if tp.err != nil {
+				errs = append(errs, tp.err)
+				continue
+			}
+
+			e, ok := r.loadHandler(tp.name)
+			if !ok {
+				e, _ = r.loadTypeHandler(f.field.Type)
+			}
+
+			checker, ok := e.handler.(handler.Checker)
+			if !ok {
+				continue
+			}
+
+			if err := checker.Check(f.field, tp.arg); err != nil {
+				errs = append(errs, &ConfigError{Type: t, Field: f.field.Name,
+					Tag: tp.name, Value: tp.value, Err: err})
+			}
+		}
+
+		if !f.stop && f.descend != nil {
+			errs = r.checkDescent(f.field.Type, f.descend, seen, errs)
+		}
+	}
+
+	return errs
+}
+
+func (r *Reflector) checkDescent(t reflect.Type, d *descent, seen map[reflect.Type]struct{}, errs []error) []error {
+	switch d.kind {
+	case descendStruct:
+		errs = r.checkStruct(t, seen, errs)
+	case descendPointer, descendElems, descendMap:
+		errs = r.checkDescent(t.Elem(), d.elem, seen, errs)
+	}
+	return errs
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/xgfone/go-structs/handler"
+	"github.com/xgfone/go-structs/handler/setter"
+)
+
+type checkFormat string
+
+func (f *checkFormat) SetFormat(s string) { *f = checkFormat(s) }
+
+func ExampleReflector_Check() {
+	parseInt := func(s string) (interface{}, error) { return strconv.ParseInt(s, 10, 64) }
+
+	sf := NewReflector()
+	sf.Register("min", handler.New(parseInt, handler.SimpleRunner(func(reflect.Value, interface{}) error { return nil })))
+	sf.Register("setfmt", handler.WithCheck(setter.SetFormatRunner(), setter.CheckSetFormat))
+
+	type Inner struct {
+		Max int64 `min:"xyz"`
+	}
+	type S struct {
+		Min    int64       `min:"abc"`
+		Format checkFormat `setfmt:"abc"`
+		String string      `setfmt:"abc"`
+		Inners []*Inner
+	}
+
+	err := sf.Check(reflect.TypeOf(S{}))
+	for _, err := range err.(MultiError) {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// structs.S.Min: invalid tag 'min' value 'abc': strconv.ParseInt: parsing "abc": invalid syntax
+	// structs.S.String: invalid tag 'setfmt' value 'abc': *string has not implemented the interface { SetFormat(string) } or { SetFormat(string) error }
+	// structs.Inner.Max: invalid tag 'min' value 'xyz': strconv.ParseInt: parsing "xyz": invalid syntax
+}
+
+func ExampleCheck() {
+	type S struct {
+		Int  int  `default:"123"`
+		Uint uint `validate:"min(1)"`
+	}
+
+	fmt.Println(Check[S]())
+	fmt.Println(Check[*S]())
+
+	// Output:
+	// <nil>
+	// <nil>
+}
@@ -0,0 +1,236 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Recursion is the decision whether to reflect a field recursively.
+type Recursion string
+
+// Predefined recursion decisions.
+const (
+	RecurseNone      Recursion = ""          // The field contains no structs.
+	RecurseInto      Recursion = "descend"   // Reflect the field recursively.
+	RecurseAlloc     Recursion = "alloc"     // Allocate the nil pointer to struct, then reflect it recursively.
+	RecurseStop      Recursion = "stop"      // Stopped by the tag `reflect:"-"`.
+	RecurseDynamic   Recursion = "dynamic"   // Decided by the struct held by the interface at runtime.
+	RecurseRecursive Recursion = "recursive" // The struct type has been explained by an ancestor field.
+)
+
+// Explanation is the explanation of what the reflector would do
+// for a struct type, which is returned by Reflector.Explain.
+type Explanation struct {
+	Type   reflect.Type
+	Fields []FieldExplanation // In the order to be reflected.
+}
+
+// FieldExplanation is the explanation of a struct field.
+type FieldExplanation struct {
+	// Path is the path of the field, such as "Order.Items[*].Count",
+	// in which "[*]" represents each element of the slice, array or map.
+	Path      string
+	Field     reflect.StructField
+	Handlers  []HandlerExplanation // In the order to run.
+	Recursion Recursion
+}
+
+// HandlerExplanation is the explanation of a handler to run on a field.
+type HandlerExplanation struct {
+	Tag      string // The tag name, that's, the handler name.
+	Value    string // The unquoted tag value.
+	Arg      any    // The argument parsed from the tag value.
+	Priority Priority
+	Phase    string // Only set when the option Phases is enabled.
+	Err      error  // The *ConfigError to parse the tag value.
+}
+
+// Explain is equal to DefaultReflector.Explain(v).
+func Explain(v any) (*Explanation, error) {
+	return DefaultReflector.Explain(v)
+}
+
+// Explain returns the explanation of which handlers would run on which
+// fields in what order, and how to reflect the fields recursively,
+// without running any handler.
+//
+// v may be a reflect.Type, reflect.Value, struct or pointer to struct,
+// and only its type is used, so the explanation is static.
+// For example, the field of interface type is explained as RecurseDynamic.
+func (r *Reflector) Explain(v any) (*Explanation, error) {
+	var t reflect.Type
+	switch _v := v.(type) {
+	case reflect.Type:
+		t = _v
+	case reflect.Value:
+		t = _v.Type()
+	default:
+		t = reflect.TypeOf(v)
+	}
+
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("the type %v is not a struct", t)
+	}
+
+	e := &Explanation{Type: t}
+	r.explainStruct(e, t.Name(), t, make(map[reflect.Type]struct{}, 4))
+	return e, nil
+}
+
+// explainStruct explains the fields of the struct type t,
+// and stack is the struct types being explained by the ancestors.
+func (r *Reflector) explainStruct(e *Explanation, path string, t reflect.Type, stack map[reflect.Type]struct{}) {
+	stack[t] = struct{}{}
+	defer delete(stack, t)
+
+	plan := r.getPlan(t)
+	fields := make(map[int]*fieldPlan, len(plan.fields))
+	for i := range plan.fields {
+		fields[plan.fields[i].index] = &plan.fields[i]
+	}
+
+	for i, _len := 0, t.NumField(); i < _len; i++ {
+		f, ok := fields[i]
+		if !ok {
+			// The field stopped by `reflect:"-"` without handlers
+			// is removed from the plan, so check it again.
+			if sf := t.Field(i); sf.IsExported() && isStopped(r.fieldTag(t, sf)) && r.compileDescent(sf.Type, false, nil) != nil {
+				e.Fields = append(e.Fields, FieldExplanation{
+					Path: joinPath(path, sf.Name), Field: sf, Recursion: RecurseStop,
+				})
+			}
+			continue
+		}
+
+		fe := FieldExplanation{Path: joinPath(path, f.field.Name), Field: f.field}
+		for j := range f.tags {
+			fe.Handlers = append(fe.Handlers, r.explainTag(&f.tags[j]))
+		}
+
+		switch {
+		case f.stop:
+			fe.Recursion = RecurseStop
+		case f.descend == nil:
+			fe.Recursion = RecurseNone
+		default:
+			fe.Recursion = explainDescent(f.field.Type, f.descend, stack)
+			if fe.Recursion == RecurseInto && f.alloc && r.hasHandlers(f.field.Type.Elem()) {
+				fe.Recursion = RecurseAlloc
+			}
+		}
+
+		e.Fields = append(e.Fields, fe)
+		if fe.Recursion == RecurseInto || fe.Recursion == RecurseAlloc {
+			r.explainDescent(e, fe.Path, f.field.Type, f.descend, stack)
+		}
+	}
+}
+
+func (r *Reflector) explainTag(t *tagPlan) HandlerExplanation {
+	he := HandlerExplanation{Tag: t.name, Value: t.value, Arg: t.arg, Priority: t.priority}
+	if len(r.phases) > 0 {
+		he.Phase = r.phases[t.phase]
+	}
+	if t.err != nil {
+		he.Err = t.err
+	}
+	return he
+}
+
+// explainDescent returns the recursion decision of the descent d of the type t.
+func explainDescent(t reflect.Type, d *descent, stack map[reflect.Type]struct{}) Recursion {
+	for {
+		switch d.kind {
+		case descendStruct:
+			if _, ok := stack[t]; ok {
+				return RecurseRecursive
+			}
+			return RecurseInto
+
+		case descendIface:
+			return RecurseDynamic
+		}
+
+		t, d = t.Elem(), d.elem
+	}
+}
+
+func (r *Reflector) explainDescent(e *Explanation, path string, t reflect.Type, d *descent, stack map[reflect.Type]struct{}) {
+	switch d.kind {
+	case descendStruct:
+		r.explainStruct(e, path, t, stack)
+	case descendPointer:
+		r.explainDescent(e, path, t.Elem(), d.elem, stack)
+	case descendElems, descendMap:
+		r.explainDescent(e, path+"[*]", t.Elem(), d.elem, stack)
+	}
+}
+
+func isStopped(tag string) (stop bool) {
+	walkTag(tag, func(name, qvalue string) {
+		if name == "reflect" {
+			flags, ok := parseReflectTag(qvalue)
+			stop = ok && flags&reflectStop != 0
+		}
+	})
+	return
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// String returns the human-readable explanation, such as
+//
+//	Order.Name string
+//	    default:"abc" arg=abc
+//	Order.Items []Item: descend
+//	Order.Items[*].Count int
+//	    min:"1" arg=1
+//	Order.Secret *Secret: stop
+func (e *Explanation) String() string {
+	var b strings.Builder
+	for _, f := range e.Fields {
+		fmt.Fprintf(&b, "%s %s", f.Path, f.Field.Type)
+		if f.Recursion != RecurseNone {
+			b.WriteString(": ")
+			b.WriteString(string(f.Recursion))
+		}
+		b.WriteByte('\n')
+
+		for _, h := range f.Handlers {
+			fmt.Fprintf(&b, "    %s:%q", h.Tag, h.Value)
+			if h.Phase != "" {
+				fmt.Fprintf(&b, " phase=%s", h.Phase)
+			}
+			if h.Err != nil {
+				fmt.Fprintf(&b, " error=%q", h.Err.Error())
+			} else {
+				fmt.Fprintf(&b, " arg=%v", h.Arg)
+			}
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/xgfone/go-structs/handler"
+)
+
+func ExampleReflector_Explain() {
+	noop := handler.SimpleRunner(func(reflect.Value, interface{}) error { return nil })
+
+	sf := NewReflector(AllocNilStructs(true))
+	sf.Register("default", noop, WithPriority(PriorityDefault))
+	sf.Register("validate", noop, WithPriority(PriorityValidate))
+	sf.Register("min", handler.New(func(s string) (any, error) { return strconv.Atoi(s) }, noop))
+
+	type Item struct {
+		Count int `min:"1"`
+	}
+	type Node struct {
+		Name string `default:"node"`
+		Next *Node
+	}
+	type Order struct {
+		Name   string `validate:"" default:"abc"`
+		Items  []Item
+		Node   *Node
+		Secret *Item `reflect:"-"`
+		Any    any
+		Ignore int
+	}
+
+	e, err := sf.Explain(reflect.TypeOf(Order{}))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Print(e)
+
+	// Output:
+	// Order.Name string
+	//     default:"abc" arg=abc
+	//     validate:"" arg=
+	// Order.Items []structs.Item: descend
+	// Order.Items[*].Count int
+	//     min:"1" arg=1
+	// Order.Node *structs.Node: alloc
+	// Order.Node.Name string
+	//     default:"node" arg=node
+	// Order.Node.Next *structs.Node: recursive
+	// Order.Secret *structs.Item: stop
+	// Order.Any interface {}: dynamic
+}
@@ -0,0 +1,196 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structs
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/xgfone/go-structs/handler"
+)
+
+// Priority is the priority of the handler, which decides the order
+// to run the handlers of a struct field regardless of the tag order.
+// The handler with the lower priority runs first.
+type Priority int
+
+// Predefined priorities.
+const (
+	PriorityNormalize Priority = 100
+	PriorityDefault   Priority = 200
+	PriorityTransform Priority = 300 // The default priority
+	PriorityValidate  Priority = 400
+)
+
+// Predefined phases, which are used by the option Phases.
+const (
+	PhaseNormalize = "normalize"
+	PhaseDefault   = "default"
+	PhaseTransform = "transform"
+	PhaseValidate  = "validate"
+)
+
+// RegisterOption is used to configure the handler to be registered.
+type RegisterOption func(*handlerEntry)
+
+// WithPriority returns a register option to set the priority of the handler.
+//
+// If not set, use the priority of the handler registered previously
+// with the same name, or PriorityTransform.
+func WithPriority(priority Priority) RegisterOption {
+	return func(e *handlerEntry) { e.priority = priority }
+}
+
+// WithPhase returns a register option to set the phase of the handler,
+// which is used only when the option Phases is set.
+//
+// If not set, use the phase of the handler registered previously
+// with the same name, or the empty phase.
+func WithPhase(phase string) RegisterOption {
+	return func(e *handlerEntry) { e.phase = phase }
+}
+
+// WithDescription returns a register option to set the summary
+// of the handler, which overrides the one returned by handler.Describer.
+//
+// Unlike the priority and phase, it is not inherited from the handler
+// registered previously with the same name.
+func WithDescription(summary string) RegisterOption {
+	return func(e *handlerEntry) { e.desc.Summary = summary }
+}
+
+// WithKinds returns a register option to set the kinds of the fields
+// supported by the handler, which overrides the ones returned by
+// handler.Describer.
+func WithKinds(kinds ...reflect.Kind) RegisterOption {
+	return func(e *handlerEntry) { e.desc.Kinds = kinds }
+}
+
+// handlerEntry is the registered handler with its metadata.
+type handlerEntry struct {
+	handler  handler.Handler
+	priority Priority
+	phase    string
+	desc     handler.Description
+}
+
+// HandlerInfo is the information of the registered handler.
+type HandlerInfo struct {
+	Name     string
+	Type     reflect.Type // Only for the type handler, see RegisterType.
+	Handler  handler.Handler
+	Priority Priority
+	Phase    string
+
+	handler.Description
+}
+
+func newHandlerInfo(name string, e handlerEntry) HandlerInfo {
+	info := HandlerInfo{Name: name, Handler: e.handler, Priority: e.priority, Phase: e.phase}
+	if d, ok := e.handler.(handler.Describer); ok {
+		info.Description = d.Describe()
+	}
+
+	if e.desc.Summary != "" {
+		info.Summary = e.desc.Summary
+	}
+	if len(e.desc.Kinds) > 0 {
+		info.Kinds = e.desc.Kinds
+	}
+	return info
+}
+
+// Handlers returns the information of all the registered handlers,
+// including the type handlers, which are sorted by the priority and then the name.
+func (r *Reflector) Handlers() []HandlerInfo {
+	handlers := r.handlers.Load().(map[string]handlerEntry)
+	typeHandlers := r.typeHandlers.Load().(map[reflect.Type]handlerEntry)
+	infos := make([]HandlerInfo, 0, len(handlers)+len(typeHandlers))
+	for name, e := range handlers {
+		infos = append(infos, newHandlerInfo(name, e))
+	}
+	for t, e := range typeHandlers {
+		info := newHandlerInfo(typeHandlerName(t), e)
+		info.Type = t
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Priority != infos[j].Priority {
+			return infos[i].Priority < infos[j].Priority
+		}
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// Lookup returns the information of the handler registered with the name,
+// which may be the name of the type handler, such as "type:time.Time".
+//
+// If not registered, ok is false.
+func (r *Reflector) Lookup(name string) (info HandlerInfo, ok bool) {
+	if e, exist := r.loadHandler(name); exist {
+		return newHandlerInfo(name, e), true
+	}
+
+	for t, e := range r.typeHandlers.Load().(map[reflect.Type]handlerEntry) {
+		if typeHandlerName(t) == name {
+			info = newHandlerInfo(name, e)
+			info.Type = t
+			return info, true
+		}
+	}
+	return
+}
+
+func newHandlerEntry(h handler.Handler, prev handlerEntry, exist bool, options []RegisterOption) handlerEntry {
+	e := handlerEntry{handler: h, priority: PriorityTransform}
+	if exist {
+		e.priority = prev.priority
+		e.phase = prev.phase
+	}
+
+	for _, option := range options {
+		option(&e)
+	}
+	return e
+}
+
+// phaseIndex returns the index of the pass in which the handler
+// with the phase runs.
+//
+// The handler whose phase is not in the phase list runs in the pass
+// of the empty phase if it is in the list, or the last pass.
+func (r *Reflector) phaseIndex(phase string) int {
+	last := len(r.phases) - 1
+	if last < 0 {
+		return 0
+	}
+
+	index := -1
+	for i, p := range r.phases {
+		switch p {
+		case phase:
+			return i
+		case "":
+			index = i
+		}
+	}
+
+	if index < 0 {
+		index = last
+	}
+	return index
+}
@@ -0,0 +1,131 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structs
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/xgfone/go-structs/handler"
+)
+
+func ExampleWithPriority() {
+	newHandler := func(name string) handler.Handler {
+		return handler.SimpleRunner(func(reflect.Value, interface{}) error {
+			fmt.Println(name)
+			return nil
+		})
+	}
+
+	type S struct {
+		Field int `validate:"" default:"" normalize:"" mask:""`
+	}
+
+	sf := NewReflector()
+	sf.Register("validate", newHandler("validate"), WithPriority(PriorityValidate))
+	sf.Register("default", newHandler("default"), WithPriority(PriorityDefault))
+	sf.Register("normalize", newHandler("normalize"), WithPriority(PriorityNormalize))
+	sf.Register("mask", newHandler("mask")) // Use PriorityTransform by default.
+	_ = sf.Reflect(&S{})
+
+	// Re-register the handler without the priority, which inherits the old.
+	sf.Register("validate", newHandler("validate2"))
+	_ = sf.Reflect(&S{})
+
+	fmt.Println("---")
+	sf = NewReflector(KeepTagOrder(true))
+	sf.Register("validate", newHandler("validate"), WithPriority(PriorityValidate))
+	sf.Register("default", newHandler("default"), WithPriority(PriorityDefault))
+	_ = sf.Reflect(&S{})
+
+	// Output:
+	// normalize
+	// default
+	// mask
+	// validate
+	// normalize
+	// default
+	// mask
+	// validate2
+	// ---
+	// validate
+	// default
+}
+
+func ExamplePhases() {
+	setdefault := handler.SimpleRunner(func(v reflect.Value, s interface{}) error {
+		if v.IsZero() {
+			v.SetString(s.(string))
+		}
+		return nil
+	})
+	validate := handler.SimpleRunner(func(v reflect.Value, _ interface{}) error {
+		if v.FieldByName("Name").IsZero() {
+			return fmt.Errorf("missing name")
+		}
+		return nil
+	})
+
+	type Child struct {
+		Name string `default:"abc"`
+	}
+	type Parent struct {
+		Child Child `validate:""` // Depend on the default of the child.
+	}
+
+	for _, sf := range []*Reflector{NewReflector(), NewReflector(Phases(PhaseDefault, PhaseValidate))} {
+		sf.Register("default", setdefault, WithPhase(PhaseDefault))
+		sf.Register("validate", validate, WithPhase(PhaseValidate))
+
+		var p Parent
+		fmt.Println(sf.Reflect(&p))
+	}
+
+	// Output:
+	// Child: missing name
+	// <nil>
+}
+
+type trimHandler struct{ handler.Runner }
+
+func (trimHandler) Describe() handler.Description {
+	return handler.Description{Summary: "trim the spaces", Kinds: []reflect.Kind{reflect.String}}
+}
+
+func ExampleReflector_Handlers() {
+	noop := handler.SimpleRunner(func(reflect.Value, interface{}) error { return nil })
+
+	sf := NewChild(DefaultReflector)
+	sf.Register("trim", trimHandler{noop}, WithPriority(PriorityNormalize))
+	sf.Register("mask", noop, WithDescription("mask the sensitive data"),
+		WithKinds(reflect.String, reflect.Slice))
+
+	for _, h := range sf.Handlers() {
+		fmt.Printf("%s: priority=%d, phase=%q, kinds=%v, %s\n", h.Name, h.Priority, h.Phase, h.Kinds, h.Summary)
+	}
+
+	if _, ok := sf.Lookup("unknown"); !ok {
+		fmt.Println("unknown: not registered")
+	}
+
+	// Output:
+	// trim: priority=100, phase="", kinds=[string], trim the spaces
+	// default: priority=200, phase="default", kinds=[], set the default value of the field if it is zero
+	// mask: priority=300, phase="", kinds=[string slice], mask the sensitive data
+	// set: priority=300, phase="transform", kinds=[], set the field by the method Set
+	// setfmt: priority=300, phase="transform", kinds=[], format the field by the method SetFormat
+	// validate: priority=400, phase="validate", kinds=[], validate the field value by the rule
+	// unknown: not registered
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structs
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/xgfone/go-structs/handler"
+)
+
+// Middleware is used to wrap the handler registered with the tag name,
+// such as logging, timing, recovering the panic or translating the error.
+type Middleware func(name string, h handler.Handler) handler.Handler
+
+// Use appends the middlewares to wrap all the handlers of the reflector,
+// including the handlers registered later.
+//
+// The middlewares only wrap the handler to run on the field, and the tag
+// value is still parsed by the original handler, so the middleware need not
+// forward the optional interfaces such as handler.FieldParser.
+//
+// The first middleware is the outermost, so it is called first.
+// And the handler passed to the middleware has been wrapped
+// by handler.Recover if the option ReturnConfigErrors is enabled.
+//
+// If the reflector has been frozen, it panics.
+func (r *Reflector) Use(middlewares ...Middleware) {
+	r.planLock.Lock()
+	defer r.planLock.Unlock()
+
+	if r.frozen.Load() {
+		panic(fmt.Errorf("the reflector has been frozen, and cannot use the middlewares"))
+	}
+
+	r.middlewares.Store(append(slices.Clip(r.loadMiddlewares()), middlewares...))
+	r.resetCaches("")
+}
+
+func (r *Reflector) loadMiddlewares() []Middleware {
+	middlewares, _ := r.middlewares.Load().([]Middleware)
+	return middlewares
+}
+
+// parseHandler returns the handler to parse the tag value by h,
+// which is not wrapped by the middlewares.
+func (r *Reflector) parseHandler(h handler.Handler) handler.Handler {
+	if r.configErr {
+		h = handler.Recover(h)
+	}
+	return h
+}
+
+// wrapHandler wraps the handler h named name by the middlewares,
+// which is used to run on the field.
+func (r *Reflector) wrapHandler(name string, h handler.Handler) handler.Handler {
+	h = r.parseHandler(h)
+	middlewares := r.loadMiddlewares()
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](name, h)
+	}
+	return h
+}
@@ -0,0 +1,85 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/xgfone/go-structs/handler"
+	"github.com/xgfone/go-structs/handler/setdefault"
+)
+
+type logHandler struct {
+	handler.Handler
+	name string
+}
+
+func (h logHandler) Run(c any, r, v reflect.Value, t reflect.StructField, a any) error {
+	err := h.Handler.Run(c, r, v, t, a)
+	fmt.Printf("run %s on %s: err=%v\n", h.name, t.Name, err)
+	return err
+}
+
+func ExampleReflector_Use() {
+	sf := NewReflector()
+	sf.Use(func(name string, h handler.Handler) handler.Handler {
+		return logHandler{Handler: h, name: name}
+	})
+
+	sf.Register("default", handler.SimpleRunner(func(v reflect.Value, s interface{}) error {
+		if v.IsZero() {
+			v.SetString(s.(string))
+		}
+		return nil
+	}))
+	sf.Register("required", handler.SimpleRunner(func(v reflect.Value, _ interface{}) error {
+		if v.IsZero() {
+			return errors.New("missing")
+		}
+		return nil
+	}))
+
+	type S struct {
+		Addr string `default:"127.0.0.1"`
+		Name string `required:""`
+	}
+
+	var s S
+	fmt.Println(sf.Reflect(&s))
+
+	// Output:
+	// run default on Addr: err=<nil>
+	// run required on Name: err=missing
+	// Name: missing
+}
+
+func ExampleReflector_Use_parse() {
+	type S struct {
+		Int8 int8 `default:"1000"`
+	}
+
+	sf := NewReflector()
+	sf.Register("default", setdefault.SetDefaultHandler())
+
+	// The middleware does not forward the interface handler.FieldParser,
+	// but the tag value is still parsed by the original handler.
+	sf.Use(func(_ string, h handler.Handler) handler.Handler { return handler.New(h.Parse, h.Run) })
+	fmt.Println(sf.Check(reflect.TypeFor[S]()))
+
+	// Output:
+	// structs.S.Int8: invalid tag 'default' value '1000': strconv.ParseInt: parsing "1000": value out of range
+}
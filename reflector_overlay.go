@@ -0,0 +1,122 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structs
+
+import (
+	"maps"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/xgfone/go-structs/handler"
+)
+
+// Overlay is a view of the Reflector with an overlay of the handlers,
+// which take precedence over the registered handlers with the same tag
+// names of the Reflector, but only for the reflections by the Overlay.
+//
+// The overlay handlers use the priorities and phases of the registered
+// handlers with the same names, or PriorityTransform and the phase ""
+// if not registered.
+//
+// The overlay handlers are also wrapped by the middlewares of the Reflector
+// when creating the Overlay, but the tag values are parsed by the original
+// overlay handlers.
+//
+// It is cheap to create an Overlay, such as for each request,
+// and it is safe to be used concurrently.
+type Overlay struct {
+	r        *Reflector
+	handlers map[string]overlayHandler
+
+	cache atomic.Value // map[tagKey]tagValue, copy-on-write
+	lock  sync.Mutex   // Only used to update the cache.
+}
+
+// Overlay returns a new Overlay of the reflector with the handlers.
+func (r *Reflector) Overlay(handlers map[string]handler.Handler) *Overlay {
+	_handlers := make(map[string]overlayHandler, len(handlers))
+	for name, h := range handlers {
+		_handlers[name] = overlayHandler{parser: r.parseHandler(h), runner: r.wrapHandler(name, h)}
+	}
+	return &Overlay{r: r, handlers: _handlers}
+}
+
+type overlayHandler struct {
+	parser handler.Handler // Only used to parse the tag value.
+	runner handler.Handler // Wrapped by the middlewares.
+}
+
+// Reflect is equal to ReflectContext(nil, structValuePtr).
+func (o *Overlay) Reflect(structValuePtr any) error {
+	return o.ReflectContext(nil, structValuePtr)
+}
+
+// ReflectValue is equal to ReflectValueContext(nil, value).
+func (o *Overlay) ReflectValue(value reflect.Value) error {
+	return o.ReflectValueContext(nil, value)
+}
+
+// ReflectContext is the same as Reflector.ReflectContext,
+// but uses the overlay handlers first.
+func (o *Overlay) ReflectContext(ctx, structValuePtr any) error {
+	if structValuePtr == nil {
+		return nil
+	}
+	return o.ReflectValueContext(ctx, reflect.ValueOf(structValuePtr))
+}
+
+// ReflectValueContext is the same as Reflector.ReflectValueContext,
+// but uses the overlay handlers first.
+func (o *Overlay) ReflectValueContext(ctx any, value reflect.Value) error {
+	return o.r.reflectValue(ctx, value, callOptions{all: o.r.collectAll, overlay: o})
+}
+
+// getTagArg returns the argument of the overlay handler h parsed
+// from the tag t of the field f of the struct type st.
+func (o *Overlay) getTagArg(st reflect.Type, f *fieldPlan, t *tagPlan, h handler.Handler) (any, *ConfigError) {
+	key := newTagKey(h, t.name, f.field, t.qvalue)
+	if tvalue, ok := o.loadTag(key); ok {
+		return tvalue.Arg, nil
+	}
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if tvalue, ok := o.loadTag(key); ok {
+		return tvalue.Arg, nil
+	}
+
+	tvalue, err := parseTagArg(h, f.field, t.qvalue)
+	if err != nil {
+		return nil, &ConfigError{Type: st, Field: f.field.Name,
+			Tag: t.name, Value: tvalue.Value, Err: err}
+	}
+
+	cache, _ := o.cache.Load().(map[tagKey]tagValue)
+	cache = maps.Clone(cache)
+	if cache == nil {
+		cache = make(map[tagKey]tagValue, 4)
+	}
+	cache[key] = tvalue
+	o.cache.Store(cache)
+	return tvalue.Arg, nil
+}
+
+func (o *Overlay) loadTag(key tagKey) (tvalue tagValue, ok bool) {
+	cache, _ := o.cache.Load().(map[tagKey]tagValue)
+	tvalue, ok = cache[key]
+	return
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structs
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/xgfone/go-structs/handler"
+)
+
+func ExampleOverlay() {
+	sf := NewReflector()
+	sf.Register("mask", handler.SimpleRunner(func(v reflect.Value, _ interface{}) error {
+		v.SetString("******")
+		return nil
+	}))
+
+	type Person struct {
+		Username string `role:"admin"`
+		Password string `mask:"password"`
+	}
+
+	// The request-scoped handler depends on the role of the caller.
+	newRoleHandler := func(callerRole string) handler.Handler {
+		return handler.SimpleRunner(func(v reflect.Value, role interface{}) error {
+			if role.(string) != callerRole {
+				v.SetString("")
+			}
+			return nil
+		})
+	}
+	noopMask := handler.SimpleRunner(func(reflect.Value, interface{}) error { return nil })
+
+	p1 := Person{Username: "user", Password: "pass"}
+	_ = sf.Overlay(map[string]handler.Handler{"role": newRoleHandler("admin")}).Reflect(&p1)
+	fmt.Printf("%+v\n", p1)
+
+	p2 := Person{Username: "user", Password: "pass"}
+	_ = sf.Overlay(map[string]handler.Handler{"role": newRoleHandler("guest"), "mask": noopMask}).Reflect(&p2)
+	fmt.Printf("%+v\n", p2)
+
+	p3 := Person{Username: "user", Password: "pass"}
+	_ = sf.Reflect(&p3) // Not affected by the overlays.
+	fmt.Printf("%+v\n", p3)
+
+	// Output:
+	// {Username:user Password:******}
+	// {Username: Password:pass}
+	// {Username:user Password:******}
+}
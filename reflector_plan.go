@@ -0,0 +1,436 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structs
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/xgfone/go-structs/handler"
+)
+
+type descendKind uint8
+
+const (
+	descendStruct  descendKind = iota + 1 // struct
+	descendPointer                        // pointer
+	descendElems                          // slice or array
+	descendMap                            // map
+	descendIface                          // interface holding a pointer to struct
+)
+
+// descent describes how to descend into a value to find the structs
+// to be reflected recursively, such as []*Struct or map[string][]Struct.
+type descent struct {
+	kind descendKind
+	elem *descent // Only for pointer, slice, array and map.
+}
+
+// structPlan is the execution plan compiled from a struct type,
+// which only contains the fields that need to be handled or descended.
+//
+// A field is descended only if the structs in it, such as *Struct or
+// map[string]Struct, have the fields to be handled, or it is an interface.
+type structPlan struct {
+	fields []fieldPlan
+
+	// handled caches whether the struct or its nested structs
+	// have the fields to be handled: 0 is unknown, 1 is yes, 2 is no.
+	handled atomic.Int32
+}
+
+type fieldPlan struct {
+	index   int
+	field   reflect.StructField
+	tags    []tagPlan
+	stop    bool     // The field has the tag `reflect:"-"`.
+	alloc   bool     // Allocate the nil pointer to struct before descending.
+	descend *descent // nil represents that the field is not descended.
+}
+
+// The options of the tag "reflect", which are separated by the comma.
+const (
+	reflectStop    = 1 << iota // "-": stop to reflect the field recursively.
+	reflectAlloc               // "alloc": allocate the nil pointer to struct.
+	reflectNoAlloc             // "noalloc": not allocate the nil pointer to struct.
+	reflectNoType              // "notype": not run the type handlers on the field.
+)
+
+// parseReflectTag parses the value of the tag "reflect".
+//
+// If the value contains an unknown option, return false.
+func parseReflectTag(qvalue string) (flags int, ok bool) {
+	if qvalue == `"-"` {
+		return reflectStop, true
+	}
+
+	for _, opt := range strings.Split(unquote(qvalue), ",") {
+		switch strings.TrimSpace(opt) {
+		case "-":
+			flags |= reflectStop
+		case "alloc":
+			flags |= reflectAlloc
+		case "noalloc":
+			flags |= reflectNoAlloc
+		case "notype":
+			flags |= reflectNoType
+		default:
+			return 0, false
+		}
+	}
+	return flags, true
+}
+
+type tagPlan struct {
+	phase    int // The index of the pass in which the handler runs.
+	priority Priority
+	name     string
+	value    string // unquoted
+	qvalue   string
+
+	// handler is nil if it is not registered, which is only in the full plan.
+	handler handler.Handler
+	arg     any
+	err     *ConfigError // The error to parse the tag value.
+}
+
+// planSet is the set of the compiled plans of the struct types.
+//
+// The plans in fulls also contain the tags whose handlers are not
+// registered, which are used by Overlay.
+type planSet struct {
+	plans map[reflect.Type]*structPlan
+	fulls map[reflect.Type]*structPlan
+}
+
+func (s planSet) get(full bool) map[reflect.Type]*structPlan {
+	if full {
+		return s.fulls
+	}
+	return s.plans
+}
+
+func (r *Reflector) updatePlans() {
+	plans := planSet{
+		plans: make(map[reflect.Type]*structPlan, len(r.planMap.plans)),
+		fulls: make(map[reflect.Type]*structPlan, len(r.planMap.fulls)),
+	}
+	for key, value := range r.planMap.plans {
+		plans.plans[key] = value
+	}
+	for key, value := range r.planMap.fulls {
+		plans.fulls[key] = value
+	}
+	r.planCache.Store(plans)
+}
+
+func (r *Reflector) loadPlan(t reflect.Type, full bool) (plan *structPlan, ok bool) {
+	plan, ok = r.planCache.Load().(planSet).get(full)[t]
+	return
+}
+
+func (r *Reflector) getPlan(t reflect.Type) *structPlan {
+	return r.getFullPlan(t, false)
+}
+
+func (r *Reflector) getFullPlan(t reflect.Type, full bool) *structPlan {
+	if plan, ok := r.loadPlan(t, full); ok {
+		return plan
+	}
+
+	r.planLock.Lock()
+	defer r.planLock.Unlock()
+
+	if plan, ok := r.loadPlan(t, full); ok {
+		return plan
+	}
+
+	plan := r.compile(t, full)
+	r.planMap.get(full)[t] = plan
+	r.updatePlans()
+	return plan
+}
+
+func (r *Reflector) compile(t reflect.Type, full bool) *structPlan {
+	plan := new(structPlan)
+	for i, _len := 0, t.NumField(); i < _len; i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		alloc, notype := r.allocNil, false
+		f := fieldPlan{index: i, field: sf, descend: r.compileDescent(sf.Type, full, nil)}
+		walkTag(r.fieldTag(t, sf), func(name, qvalue string) {
+			if name == "reflect" {
+				if flags, ok := parseReflectTag(qvalue); ok {
+					f.stop = flags&reflectStop != 0
+					notype = flags&reflectNoType != 0
+					alloc = (alloc || flags&reflectAlloc != 0) && flags&reflectNoAlloc == 0
+					return
+				}
+			}
+
+			if e, ok := r.loadHandler(name); ok {
+				f.tags = append(f.tags, r.compileTag(t, sf, e, name, qvalue))
+			} else if full {
+				f.tags = append(f.tags, tagPlan{
+					phase:    r.phaseIndex(""),
+					priority: PriorityTransform,
+					name:     name,
+					value:    unquote(qvalue),
+					qvalue:   qvalue,
+				})
+			}
+		})
+
+		if e, ok := r.loadTypeHandler(sf.Type); ok && !notype {
+			f.tags = append(f.tags, r.compileTag(t, sf, e, typeHandlerName(sf.Type), `""`))
+		}
+
+		if !r.tagOrder {
+			sort.SliceStable(f.tags, func(i, j int) bool {
+				return f.tags[i].priority < f.tags[j].priority
+			})
+		}
+
+		if alloc && sf.Type.Kind() == reflect.Pointer && sf.Type.Elem().Kind() == reflect.Struct {
+			f.alloc = !f.stop && f.descend != nil
+		}
+
+		if len(f.tags) > 0 || (!f.stop && f.descend != nil) {
+			plan.fields = append(plan.fields, f)
+		}
+	}
+	return plan
+}
+
+// compileTag compiles the tag of the field sf of the struct type t
+// with the handler entry e.
+func (r *Reflector) compileTag(t reflect.Type, sf reflect.StructField, e handlerEntry, name, qvalue string) tagPlan {
+	tv, err := r.getTagArg(r.parseHandler(e.handler), name, sf, qvalue)
+	tp := tagPlan{
+		phase:    r.phaseIndex(e.phase),
+		priority: e.priority,
+		name:     name,
+		value:    tv.Value,
+		qvalue:   qvalue,
+		handler:  r.wrapHandler(name, e.handler),
+		arg:      tv.Arg,
+	}
+	if err != nil {
+		tp.err = &ConfigError{Type: t, Field: sf.Name, Tag: name, Value: tv.Value, Err: err}
+	}
+	return tp
+}
+
+// hasHandlers reports whether the struct type t, or its nested structs
+// which are not nil or can be allocated, has the fields to be handled.
+func (r *Reflector) hasHandlers(t reflect.Type) bool {
+	plan := r.getPlan(t)
+	switch plan.handled.Load() {
+	case 1:
+		return true
+	case 2:
+		return false
+	}
+
+	// Only cache the result of the top struct, because the result of
+	// the nested struct may be incomplete when the types are recursive.
+	ok := r.containsHandlers(plan, make(map[*structPlan]struct{}, 4))
+	if ok {
+		plan.handled.Store(1)
+	} else {
+		plan.handled.Store(2)
+	}
+	return ok
+}
+
+func (r *Reflector) containsHandlers(plan *structPlan, seen map[*structPlan]struct{}) bool {
+	if _, ok := seen[plan]; ok {
+		return false
+	}
+	seen[plan] = struct{}{}
+
+	for i := range plan.fields {
+		f := &plan.fields[i]
+		switch {
+		case len(f.tags) > 0:
+			return true
+
+		case f.stop || f.descend == nil:
+
+		case f.descend.kind == descendStruct:
+			if r.containsHandlers(r.getPlan(f.field.Type), seen) {
+				return true
+			}
+
+		case f.alloc:
+			if r.containsHandlers(r.getPlan(f.field.Type.Elem()), seen) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// compileDescent compiles the descent of the type t, which is nil
+// if t contains no structs with the fields to be handled.
+//
+// seen is used to avoid the infinite recursion of the recursive types,
+// such as "type Tree map[string]Tree" or "type Node struct{ Next *Node }".
+func (r *Reflector) compileDescent(t reflect.Type, full bool, seen map[reflect.Type]struct{}) *descent {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Pointer, reflect.Array, reflect.Slice, reflect.Map:
+		if _, ok := seen[t]; ok {
+			return nil
+		} else if seen == nil {
+			seen = make(map[reflect.Type]struct{}, 4)
+		}
+		seen[t] = struct{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if r.containsHandledFields(t, full, seen) {
+			return &descent{kind: descendStruct}
+		}
+
+	case reflect.Interface:
+		if !r.noIfaces {
+			return &descent{kind: descendIface}
+		}
+
+	case reflect.Pointer:
+		if elem := r.compileDescent(t.Elem(), full, seen); elem != nil {
+			return &descent{kind: descendPointer, elem: elem}
+		}
+
+	case reflect.Array, reflect.Slice:
+		if elem := r.compileDescent(t.Elem(), full, seen); elem != nil {
+			return &descent{kind: descendElems, elem: elem}
+		}
+
+	case reflect.Map:
+		if r.noMaps {
+			break
+		}
+
+		if elem := r.compileDescent(t.Elem(), full, seen); elem != nil {
+			return &descent{kind: descendMap, elem: elem}
+		}
+	}
+	return nil
+}
+
+// containsHandledFields reports whether the struct type t, or the structs
+// in its fields, have the fields to be handled by the tag or type handlers,
+// or the interface fields to be descended.
+//
+// For the full plan, the tags whose handlers are not registered are also
+// regarded as handled, because they may be handled by Overlay.
+func (r *Reflector) containsHandledFields(t reflect.Type, full bool, seen map[reflect.Type]struct{}) bool {
+	for i, _len := 0, t.NumField(); i < _len; i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		var handled, stop, notype bool
+		walkTag(r.fieldTag(t, sf), func(name, qvalue string) {
+			if name == "reflect" {
+				if flags, ok := parseReflectTag(qvalue); ok {
+					stop = flags&reflectStop != 0
+					notype = flags&reflectNoType != 0
+					return
+				}
+			}
+
+			if !handled {
+				_, handled = r.loadHandler(name)
+				handled = handled || full
+			}
+		})
+
+		if !handled && !notype {
+			_, handled = r.loadTypeHandler(sf.Type)
+		}
+
+		if handled || (!stop && r.compileDescent(sf.Type, full, seen) != nil) {
+			return true
+		}
+	}
+	return false
+}
+
+func unquote(s string) string {
+	if _s, err := strconv.Unquote(s); err == nil {
+		return strings.TrimSpace(_s)
+	}
+	return s
+}
+
+// copy and modify from https://github.com/golang/go/blob/go1.18.4/src/reflect/type.go
+//
+// It reports whether the whole tag has been walked as the valid pairs.
+func walkTag(tag string, do func(name, qvalue string)) bool {
+	for tag != "" {
+		// Skip leading space.
+		i := 0
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		tag = tag[i:]
+		if tag == "" {
+			break
+		}
+
+		// Scan to colon. A space, a quote or a control character is a syntax error.
+		// Strictly speaking, control chars include the range [0x7f, 0x9f], not just
+		// [0x00, 0x1f], but in practice, we ignore the multi-byte control characters
+		// as it is simpler to inspect the tag's bytes than the tag's runes.
+		i = 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			return false
+		}
+		name := string(tag[:i])
+		tag = tag[i+1:]
+
+		// Scan quoted string to find value.
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			return false
+		}
+		qvalue := string(tag[:i+1])
+		tag = tag[i+1:]
+
+		// (xgfone): Poll the key-value tag.
+		do(name, qvalue)
+	}
+	return true
+}
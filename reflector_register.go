@@ -15,14 +15,26 @@
 package structs
 
 import (
+	"github.com/xgfone/go-structs/handler"
 	"github.com/xgfone/go-structs/handler/setdefault"
 	"github.com/xgfone/go-structs/handler/setter"
 	"github.com/xgfone/go-structs/handler/validate"
 )
 
 func init() {
-	Register("validate", validate.ValidateStructFieldRunner(nil))
-	Register("default", setdefault.SetDefaultRunner())
-	Register("setfmt", setter.SetFormatRunner())
-	Register("set", setter.SetterRunner(nil))
+	Register("validate", validate.ValidateStructFieldRunner(nil),
+		WithPriority(PriorityValidate), WithPhase(PhaseValidate),
+		WithDescription("validate the field value by the rule"))
+
+	Register("default", setdefault.SetDefaultHandler(),
+		WithPriority(PriorityDefault), WithPhase(PhaseDefault),
+		WithDescription("set the default value of the field if it is zero"))
+
+	Register("setfmt", handler.WithCheck(setter.SetFormatRunner(), setter.CheckSetFormat),
+		WithPriority(PriorityTransform), WithPhase(PhaseTransform),
+		WithDescription("format the field by the method SetFormat"))
+
+	Register("set", handler.WithCheck(setter.SetterRunner(nil), setter.CheckSetter),
+		WithPriority(PriorityTransform), WithPhase(PhaseTransform),
+		WithDescription("set the field by the method Set"))
 }
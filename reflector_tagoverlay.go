@@ -0,0 +1,283 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structs
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// TagOverlay is a builder of the virtual tags attached to the fields
+// of the struct types, such as the types from the third-party packages
+// whose tags cannot be edited, which is added into the reflector
+// by Reflector.AddTagOverlay.
+//
+// The virtual tags are treated as if they were on the struct fields,
+// which are merged with or override the real tags.
+type TagOverlay struct {
+	tags map[reflect.Type]map[string]overlayTag
+	err  error
+}
+
+type overlayTag struct {
+	tag      string
+	override bool
+}
+
+// NewTagOverlay returns a new TagOverlay.
+func NewTagOverlay() *TagOverlay {
+	return &TagOverlay{tags: make(map[reflect.Type]map[string]overlayTag, 4)}
+}
+
+// Merge merges the virtual tag into the real tag of the field of the struct
+// type t, which takes precedence over the real tag with the same name.
+//
+// path is the path of the field, such as "Name" or "TLS.CertFile", in which
+// the intermediate field may be a struct, or a pointer, slice, array or map
+// of structs. Notice: the tag is attached to the field of the struct type
+// that the path ends with, so it also takes effect on that struct type
+// used elsewhere.
+//
+// If failing, the error is returned by Err and Reflector.AddTagOverlay.
+func (o *TagOverlay) Merge(t reflect.Type, path string, tag reflect.StructTag) *TagOverlay {
+	return o.add(t, path, string(tag), false)
+}
+
+// Override is the same as Merge, but the virtual tag replaces all the real
+// tags of the field, including the tag "reflect".
+func (o *TagOverlay) Override(t reflect.Type, path string, tag reflect.StructTag) *TagOverlay {
+	return o.add(t, path, string(tag), true)
+}
+
+// Err returns the first error occurred when building the tag overlay.
+func (o *TagOverlay) Err() error { return o.err }
+
+func (o *TagOverlay) add(t reflect.Type, path, tag string, override bool) *TagOverlay {
+	if o.err != nil {
+		return o
+	}
+
+	st, name, err := resolveFieldPath(t, path)
+	if err == nil && strings.TrimSpace(tag) != "" && !isValidTag(tag) {
+		err = fmt.Errorf("invalid tag `%s`", tag)
+	}
+	if err != nil {
+		o.err = fmt.Errorf("%v.%s: %w", t, path, err)
+		return o
+	}
+
+	fields, ok := o.tags[st]
+	if !ok {
+		fields = make(map[string]overlayTag, 4)
+		o.tags[st] = fields
+	}
+
+	if prev, ok := fields[name]; ok {
+		tag, override = mergeTags(prev.tag, tag), prev.override || override
+	}
+	fields[name] = overlayTag{tag: tag, override: override}
+	return o
+}
+
+// LoadJSON loads the virtual tags from the JSON data, which is a list of
+// the virtual tags like
+//
+//	[
+//	  {"type": "pkg.Config", "field": "Addr", "tag": "default:\":80\""},
+//	  {"type": "pkg.Config", "field": "TLS.CertFile", "tag": "validate:\"required\"", "override": true}
+//	]
+//
+// The type is matched by the string or the full name with the package path
+// of one of the given types, such as "pkg.Config" or "github.com/x/pkg.Config".
+//
+// If failing, the error is returned by Err and Reflector.AddTagOverlay.
+func (o *TagOverlay) LoadJSON(data []byte, types ...reflect.Type) *TagOverlay {
+	if o.err != nil {
+		return o
+	}
+
+	var tags []struct {
+		Type     string `json:"type"`
+		Field    string `json:"field"`
+		Tag      string `json:"tag"`
+		Override bool   `json:"override"`
+	}
+	if err := json.Unmarshal(data, &tags); err != nil {
+		o.err = fmt.Errorf("fail to decode the tag overlay: %w", err)
+		return o
+	}
+
+	for _, tag := range tags {
+		i := findType(types, tag.Type)
+		if i < 0 {
+			o.err = fmt.Errorf("not found the type '%s' of the tag overlay", tag.Type)
+			return o
+		}
+		o.add(types[i], tag.Field, tag.Tag, tag.Override)
+	}
+	return o
+}
+
+// LoadJSONFile is the same as LoadJSON, but reads the JSON data from the file.
+func (o *TagOverlay) LoadJSONFile(filename string, types ...reflect.Type) *TagOverlay {
+	if o.err != nil {
+		return o
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		o.err = err
+		return o
+	}
+	return o.LoadJSON(data, types...)
+}
+
+func findType(types []reflect.Type, name string) int {
+	for i, t := range types {
+		if t.String() == name || (t.PkgPath() != "" && t.PkgPath()+"."+t.Name() == name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// resolveFieldPath resolves the field path of the struct type t,
+// and returns the struct type declaring the field and the field name.
+func resolveFieldPath(t reflect.Type, path string) (reflect.Type, string, error) {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		for k := t.Kind(); k == reflect.Pointer || k == reflect.Slice || k == reflect.Array || k == reflect.Map; k = t.Kind() {
+			t = t.Elem()
+		}
+
+		if t.Kind() != reflect.Struct {
+			return nil, "", fmt.Errorf("the type %s of '%s' is not a struct", t, strings.Join(names[:i], "."))
+		}
+
+		sf, ok := t.FieldByName(name)
+		if !ok || !sf.IsExported() {
+			return nil, "", fmt.Errorf("not found the field '%s'", strings.Join(names[:i+1], "."))
+		}
+
+		if i < len(names)-1 {
+			t = sf.Type
+			continue
+		}
+
+		// The field may be promoted from the embedded struct.
+		for _, index := range sf.Index[:len(sf.Index)-1] {
+			if t = t.Field(index).Type; t.Kind() == reflect.Pointer {
+				t = t.Elem()
+			}
+		}
+	}
+
+	return t, names[len(names)-1], nil
+}
+
+// isValidTag reports whether the whole tag consists of the valid pairs
+// like `name:"value"`, and each value is a valid quoted string.
+func isValidTag(tag string) bool {
+	valid := true
+	ok := walkTag(tag, func(_, qvalue string) {
+		if _, err := strconv.Unquote(qvalue); err != nil {
+			valid = false
+		}
+	})
+	return ok && valid
+}
+
+// mergeTags merges the tag overlay into the tag base,
+// and the tag in overlay takes precedence over the one with the same name.
+func mergeTags(base, overlay string) string {
+	names := make(map[string]struct{}, 4)
+	walkTag(overlay, func(name, _ string) { names[name] = struct{}{} })
+
+	var b strings.Builder
+	walkTag(base, func(name, qvalue string) {
+		if _, ok := names[name]; !ok {
+			b.WriteString(name)
+			b.WriteByte(':')
+			b.WriteString(qvalue)
+			b.WriteByte(' ')
+		}
+	})
+	b.WriteString(overlay)
+	return b.String()
+}
+
+// AddTagOverlay adds the virtual tags of the tag overlay into the reflector,
+// which are merged with the virtual tags added previously.
+//
+// If the reflector has been frozen, it panics.
+func (r *Reflector) AddTagOverlay(o *TagOverlay) error {
+	if o.err != nil {
+		return o.err
+	}
+
+	r.planLock.Lock()
+	defer r.planLock.Unlock()
+
+	if r.frozen.Load() {
+		panic(fmt.Errorf("the reflector has been frozen, and cannot add the tag overlay"))
+	}
+
+	for t, fields := range o.tags {
+		_fields := maps.Clone(r.tagOverlayMap[t])
+		if _fields == nil {
+			_fields = make(map[string]overlayTag, len(fields))
+		}
+
+		for name, tag := range fields {
+			if prev, ok := _fields[name]; ok {
+				tag = overlayTag{tag: mergeTags(prev.tag, tag.tag), override: prev.override || tag.override}
+			}
+			_fields[name] = tag
+		}
+		r.tagOverlayMap[t] = _fields
+	}
+
+	r.updateTagOverlays()
+	clear(r.planMap.plans)
+	clear(r.planMap.fulls)
+	r.updatePlans()
+	return nil
+}
+
+func (r *Reflector) updateTagOverlays() {
+	tags := make(map[reflect.Type]map[string]overlayTag, len(r.tagOverlayMap))
+	for t, fields := range r.tagOverlayMap {
+		tags[t] = fields
+	}
+	r.tagOverlays.Store(tags)
+}
+
+// fieldTag returns the tag of the field sf of the struct type t,
+// which has been merged with the virtual tags.
+func (r *Reflector) fieldTag(t reflect.Type, sf reflect.StructField) string {
+	tags := r.tagOverlays.Load().(map[reflect.Type]map[string]overlayTag)
+	if tag, ok := tags[t][sf.Name]; ok {
+		if tag.override {
+			return tag.tag
+		}
+		return mergeTags(string(sf.Tag), tag.tag)
+	}
+	return string(sf.Tag)
+}
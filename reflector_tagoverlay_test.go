@@ -0,0 +1,72 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structs
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Assume that they are from a third-party package.
+type (
+	thirdTLSConfig struct {
+		CertFile string
+		KeyFile  string `default:"key.pem"`
+	}
+
+	thirdConfig struct {
+		Addr    string
+		Timeout int `default:"10"`
+		TLS     *thirdTLSConfig
+	}
+)
+
+func ExampleTagOverlay() {
+	sf := NewChild(DefaultReflector)
+
+	configType := reflect.TypeOf(thirdConfig{})
+	overlay := NewTagOverlay().
+		Merge(configType, "Addr", `default:":80"`).
+		Override(configType, "Timeout", `default:"30"`).
+		LoadJSON([]byte(`[
+			{"type": "structs.thirdConfig", "field": "TLS", "tag": "reflect:\"alloc\""},
+			{"type": "structs.thirdConfig", "field": "TLS.CertFile", "tag": "default:\"cert.pem\""},
+			{"type": "structs.thirdConfig", "field": "TLS.KeyFile", "tag": "default:\"tls.key\""}
+		]`), configType)
+
+	if err := sf.AddTagOverlay(overlay); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	var c thirdConfig
+	if err := sf.Reflect(&c); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println(c.Addr, c.Timeout, c.TLS.CertFile, c.TLS.KeyFile)
+
+	err := sf.AddTagOverlay(NewTagOverlay().Merge(configType, "TLS.Unknown", `default:""`))
+	fmt.Println(err)
+
+	err = sf.AddTagOverlay(NewTagOverlay().Merge(configType, "Addr", `default:":80" garbage`))
+	fmt.Println(err)
+
+	// Output:
+	// :80 30 cert.pem tls.key
+	// structs.thirdConfig.TLS.Unknown: not found the field 'TLS.Unknown'
+	// structs.thirdConfig.Addr: invalid tag `default:":80" garbage`
+}
@@ -15,9 +15,13 @@
 package structs
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
+	"sync"
+	"testing"
 	"unicode/utf8"
 
 	"github.com/xgfone/go-structs/handler"
@@ -125,3 +129,554 @@ func ExampleReflector() {
 	// Response.request.Page: 0
 	// Response.request.PageSize: 0
 }
+
+func ExampleReflector_ReflectAll() {
+	sf := NewReflector()
+	sf.Register("nonzero", handler.SimpleRunner(func(v reflect.Value, _ interface{}) error {
+		if v.IsZero() {
+			return errors.New("the value must not be ZERO")
+		}
+		return nil
+	}))
+
+	type Request struct {
+		Name string `nonzero:""`
+		Page int64  `nonzero:""`
+		Size int64  `nonzero:""`
+	}
+
+	request := Request{Page: 1}
+	fmt.Println(sf.Reflect(&request))
+	fmt.Println("---")
+
+	err := sf.ReflectAll(nil, &request)
+	fmt.Println(err)
+	fmt.Println("---")
+
+	var merr MultiError
+	if errors.As(err, &merr) {
+		fmt.Println(len(merr))
+	}
+
+	request.Name = "abc"
+	if errors.As(sf.ReflectAll(nil, &request), &merr) {
+		fmt.Println(len(merr))
+	}
+
+	// Output:
+	// Name: the value must not be ZERO
+	// ---
+	// Name: the value must not be ZERO
+	// Size: the value must not be ZERO
+	// ---
+	// 2
+	// 1
+}
+
+func ExampleFieldError() {
+	sf := NewReflector()
+	sf.Register("nonzero", handler.SimpleRunner(func(v reflect.Value, _ interface{}) error {
+		if v.IsZero() {
+			return errors.New("the value must not be ZERO")
+		}
+		return nil
+	}))
+
+	type Person struct {
+		Username string `json:"user_name" nonzero:"username"`
+	}
+	type Response struct {
+		Persons []Person
+	}
+
+	response := Response{Persons: []Person{{Username: "a"}, {}}}
+	err := sf.Reflect(&response)
+
+	var ferr *FieldError
+	if errors.As(err, &ferr) {
+		fmt.Println(ferr.Path)
+		fmt.Println(ferr.NamePath)
+		fmt.Println(ferr.Field)
+		fmt.Println(ferr.Name)
+		fmt.Println(ferr.Tag)
+		fmt.Println(ferr.Value)
+		fmt.Println(ferr.Err)
+		fmt.Println(ferr)
+	}
+
+	// Output:
+	// Response.Persons[1].Username
+	// Persons[1].user_name
+	// Username
+	// user_name
+	// nonzero
+	// username
+	// the value must not be ZERO
+	// Persons[1].user_name: the value must not be ZERO
+}
+
+func ExampleReflectMapValues() {
+	setdefault := handler.SimpleRunner(func(v reflect.Value, s interface{}) error {
+		if v.IsZero() {
+			v.SetString(s.(string))
+		}
+		return nil
+	})
+
+	type Item struct {
+		Name string `default:"abc"`
+	}
+	type S struct {
+		Items    map[string]Item
+		ItemPtrs map[string]*Item
+		Iface    interface{}
+	}
+
+	newS := func() S {
+		return S{
+			Items:    map[string]Item{"a": {}},
+			ItemPtrs: map[string]*Item{"b": {}},
+			Iface:    &Item{},
+		}
+	}
+
+	sf := NewReflector()
+	sf.Register("default", setdefault)
+	s := newS()
+	if err := sf.Reflect(&s); err != nil {
+		fmt.Println(err)
+	} else {
+		fmt.Printf("Items: %q\n", s.Items["a"].Name)
+		fmt.Printf("ItemPtrs: %q\n", s.ItemPtrs["b"].Name)
+		fmt.Printf("Iface: %q\n", s.Iface.(*Item).Name)
+	}
+
+	sf = NewReflector(ReflectMapValues(false), ReflectInterfaces(false))
+	sf.Register("default", setdefault)
+	s = newS()
+	if err := sf.Reflect(&s); err != nil {
+		fmt.Println(err)
+	} else {
+		fmt.Printf("Items: %q\n", s.Items["a"].Name)
+		fmt.Printf("ItemPtrs: %q\n", s.ItemPtrs["b"].Name)
+		fmt.Printf("Iface: %q\n", s.Iface.(*Item).Name)
+	}
+
+	// Output:
+	// Items: "abc"
+	// ItemPtrs: "abc"
+	// Iface: "abc"
+	// Items: ""
+	// ItemPtrs: ""
+	// Iface: ""
+}
+
+func ExampleReflector_pointerElements() {
+	sf := NewReflector()
+	sf.Register("mask", handler.SimpleRunner(func(v reflect.Value, _ interface{}) error {
+		v.SetString("******")
+		return nil
+	}))
+
+	type Person struct {
+		Password string `mask:""`
+	}
+	type Response struct {
+		Persons []*Person
+		Groups  [][]Person
+		Arrays  map[string][1]Person
+	}
+
+	response := Response{
+		Persons: []*Person{{Password: "123"}, nil},
+		Groups:  [][]Person{{{Password: "456"}}},
+		Arrays:  map[string][1]Person{"a": {{Password: "789"}}},
+	}
+	if err := sf.Reflect(&response); err != nil {
+		fmt.Println(err)
+	} else {
+		fmt.Println(response.Persons[0].Password)
+		fmt.Println(response.Groups[0][0].Password)
+		fmt.Println(response.Arrays["a"][0].Password)
+	}
+
+	// Output:
+	// ******
+	// ******
+	// ******
+}
+
+func ExampleReturnCycleError() {
+	type Node struct {
+		Name   string `count:""`
+		Parent *Node
+		Nodes  []*Node
+	}
+
+	var count int
+	counter := handler.SimpleRunner(func(reflect.Value, interface{}) error {
+		count++
+		return nil
+	})
+
+	root := &Node{Name: "root"}
+	child := &Node{Name: "child", Parent: root}
+	root.Nodes = []*Node{child, child} // child is shared
+
+	sf := NewReflector()
+	sf.Register("count", counter)
+	fmt.Println(sf.Reflect(root), count)
+
+	count = 0
+	sf = NewReflector(ReturnCycleError(true))
+	sf.Register("count", counter)
+	err := sf.Reflect(root)
+	fmt.Println(err, errors.Is(err, ErrCycle), count)
+
+	// Output:
+	// <nil> 2
+	// Nodes[0].Parent: cyclic pointer reference true 2
+}
+
+func ExampleAllocNilStructs() {
+	setdefault := handler.SimpleRunner(func(v reflect.Value, s interface{}) error {
+		if v.IsZero() {
+			v.SetString(s.(string))
+		}
+		return nil
+	})
+
+	type TLSConfig struct {
+		CertFile string `default:"cert.pem"`
+	}
+	type Other struct {
+		Name string
+	}
+	type Config struct {
+		TLS1  *TLSConfig
+		TLS2  *TLSConfig `reflect:"noalloc"`
+		Other *Other     // No fields need to be handled.
+	}
+	type Config2 struct {
+		TLS1 *TLSConfig `reflect:"alloc"`
+		TLS2 *TLSConfig
+	}
+
+	sf := NewReflector(AllocNilStructs(true))
+	sf.Register("default", setdefault)
+
+	var c Config
+	if err := sf.Reflect(&c); err != nil {
+		fmt.Println(err)
+	} else {
+		fmt.Println(c.TLS1.CertFile, c.TLS2 == nil, c.Other == nil)
+	}
+
+	sf = NewReflector()
+	sf.Register("default", setdefault)
+
+	var c2 Config2
+	if err := sf.Reflect(&c2); err != nil {
+		fmt.Println(err)
+	} else {
+		fmt.Println(c2.TLS1.CertFile, c2.TLS2 == nil)
+	}
+
+	// Output:
+	// cert.pem true true
+	// cert.pem true
+}
+
+func ExampleReturnConfigErrors() {
+	parseInt := func(s string) (interface{}, error) { return strconv.ParseInt(s, 10, 64) }
+	sf := NewReflector(ReturnConfigErrors(true))
+	sf.Register("min", handler.New(parseInt, func(_ interface{}, _, v reflect.Value, _ reflect.StructField, a interface{}) error {
+		if v.Int() < a.(int64) {
+			return fmt.Errorf("the value is less than %d", a)
+		}
+		return nil
+	}))
+	sf.Register("panic", handler.SimpleRunner(func(reflect.Value, interface{}) error {
+		panic("the field does not match the tag")
+	}))
+
+	type S1 struct {
+		Int int64 `min:"abc"`
+	}
+	type S2 struct {
+		Int int64 `panic:""`
+	}
+
+	var cerr *ConfigError
+	if err := sf.Reflect(&S1{}); errors.As(err, &cerr) {
+		fmt.Println(cerr.Type.Name(), cerr.Field, cerr.Tag, cerr.Value)
+	}
+	if err := sf.Reflect(&S2{}); errors.As(err, &cerr) {
+		fmt.Println(cerr.Type.Name(), cerr.Field, cerr.Tag)
+		fmt.Println(err)
+	}
+
+	// Output:
+	// S1 Int min abc
+	// S2 Int panic
+	// structs.S2.Int: invalid tag 'panic' value '': panic: the field does not match the tag
+}
+
+func ExampleReflector_Freeze() {
+	noop := handler.SimpleRunner(func(reflect.Value, interface{}) error { return nil })
+
+	sf := NewReflector()
+	sf.Register("noop", noop)
+	sf.Freeze()
+
+	defer func() { fmt.Println(recover()) }()
+	fmt.Println(sf.Frozen())
+	sf.Register("noop2", noop)
+
+	// Output:
+	// true
+	// the reflector has been frozen, and cannot update the handler 'noop2'
+}
+
+func TestReflectorRegisterConcurrently(t *testing.T) {
+	type S struct {
+		F1 int `h0:"" h1:"" h2:"" h3:""`
+		F2 struct {
+			F3 int `h0:"" h1:"" h2:"" h3:""`
+		}
+	}
+
+	sf := NewReflector()
+	noop := handler.SimpleRunner(func(reflect.Value, interface{}) error { return nil })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(name string) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				sf.Register(name, noop)
+				sf.Unregister(name)
+			}
+		}("h" + strconv.Itoa(i))
+
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				var s S
+				if err := sf.Reflect(&s); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestReflectSharedMapConcurrently(t *testing.T) {
+	type Item struct {
+		Name  string `json:"name"`
+		Alias string `upper:""`
+	}
+	type S struct {
+		Items map[string]Item // Descended, because Item.Alias is handled.
+		Names map[string]struct{ Name string }
+	}
+
+	sf := NewReflector()
+	sf.Register("upper", handler.SimpleRunner(func(v reflect.Value, _ interface{}) error {
+		v.SetString(strings.ToUpper(v.String()))
+		return nil
+	}))
+
+	// The shared maps are read-only, which must not be written back
+	// if no handler runs on their values.
+	items := map[string]Item{"a": {Name: "a"}, "b": {Name: "b"}}
+	names := map[string]struct{ Name string }{"a": {Name: "a"}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				s := S{Items: items, Names: names}
+				if err := sf.ReflectExcept(nil, &s, "upper"); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				for _, item := range items {
+					_ = item.Name
+				}
+				_ = names["a"].Name
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func ExampleReflector_ReflectOnly() {
+	sf := NewReflector()
+	sf.Register("default", handler.SimpleRunner(func(v reflect.Value, s interface{}) error {
+		if v.IsZero() {
+			v.SetString(s.(string))
+		}
+		return nil
+	}))
+	sf.Register("validate", handler.SimpleRunner(func(v reflect.Value, _ interface{}) error {
+		if v.String() == "" {
+			return errors.New("the value must not be empty")
+		}
+		return nil
+	}))
+
+	type Config struct {
+		Addr string `default:"127.0.0.1:80" validate:""`
+		Name string `validate:""`
+	}
+
+	var c1 Config
+	fmt.Println(sf.ReflectOnly(nil, &c1, "default"), c1.Addr)
+
+	var c2 Config
+	fmt.Println(sf.ReflectExcept(nil, &c2, "default"), c2.Addr == "")
+
+	// Output:
+	// <nil> 127.0.0.1:80
+	// Addr: the value must not be empty true
+}
+
+func ExampleNewChild() {
+	parent := NewReflector()
+	parent.Register("upper", handler.SimpleRunner(func(v reflect.Value, _ interface{}) error {
+		v.SetString(strings.ToUpper(v.String()))
+		return nil
+	}))
+
+	child := NewChild(parent, CollectAllErrors(true))
+	child.Register("upper", handler.SimpleRunner(func(v reflect.Value, _ interface{}) error {
+		v.SetString("<" + strings.ToUpper(v.String()) + ">")
+		return nil
+	}))
+	child.Register("trim", handler.SimpleRunner(func(v reflect.Value, _ interface{}) error {
+		v.SetString(strings.TrimSpace(v.String()))
+		return nil
+	}))
+
+	type S struct {
+		Name string `trim:"" upper:""`
+	}
+
+	s1 := S{Name: " abc "}
+	_ = parent.Reflect(&s1)
+	fmt.Printf("%q\n", s1.Name)
+
+	s2 := S{Name: " abc "}
+	_ = child.Reflect(&s2)
+	fmt.Printf("%q\n", s2.Name)
+
+	// Output:
+	// " ABC "
+	// "<ABC>"
+}
+
+func ExampleReflector_Clone() {
+	var parses int
+	parse := func(s string) (any, error) { parses++; return s, nil }
+
+	parent := NewReflector()
+	parent.Register("prefix", handler.New(parse, handler.SimpleRunner(func(v reflect.Value, arg interface{}) error {
+		v.SetString(arg.(string) + v.String())
+		return nil
+	})))
+
+	type S struct {
+		Name string `prefix:"p-"`
+	}
+
+	s := S{Name: "a"}
+	_ = parent.Reflect(&s)
+	fmt.Println(s.Name, parses)
+
+	// The clone inherits the handlers and the cached plans,
+	// so the tag is not parsed again.
+	clone := parent.Clone()
+	s = S{Name: "b"}
+	_ = clone.Reflect(&s)
+	fmt.Println(s.Name, parses)
+
+	// Overriding the handler of the clone does not affect the parent.
+	clone.Register("prefix", handler.New(parse, handler.SimpleRunner(func(v reflect.Value, arg interface{}) error {
+		v.SetString(strings.ToUpper(arg.(string)) + v.String())
+		return nil
+	})))
+
+	s = S{Name: "c"}
+	_ = clone.Reflect(&s)
+	fmt.Println(s.Name, parses)
+
+	s = S{Name: "d"}
+	_ = parent.Reflect(&s)
+	fmt.Println(s.Name, parses)
+
+	// Output:
+	// p-a 1
+	// p-b 1
+	// P-c 2
+	// p-d 2
+}
+
+func ExampleReflector_ReflectContext_controlFlow() {
+	sf := NewReflector()
+	sf.Register("perm", handler.Runner(func(ctx any, _, v reflect.Value, _ reflect.StructField, arg any) error {
+		if ctx.(string) != arg.(string) {
+			v.Set(reflect.Zero(v.Type()))
+			return handler.SkipChildren // Prune the subtree for the role.
+		}
+		return nil
+	}))
+	sf.Register("mask", handler.SimpleRunner(func(v reflect.Value, _ interface{}) error {
+		if v.String() == "" {
+			return handler.SkipRemainingTags
+		}
+		v.SetString("***")
+		return nil
+	}), WithPriority(PriorityNormalize))
+	sf.Register("required", handler.SimpleRunner(func(v reflect.Value, _ interface{}) error {
+		if v.String() == "" {
+			return errors.New("missing")
+		}
+		return nil
+	}))
+	sf.Register("stop", handler.SimpleRunner(func(v reflect.Value, _ interface{}) error {
+		if v.Bool() {
+			return handler.StopAll
+		}
+		return nil
+	}))
+
+	type Secret struct {
+		Token string `mask:"" required:""`
+	}
+	type User struct {
+		Name   string
+		Secret Secret `perm:"admin"`
+		Skip   bool   `stop:""`
+		Tail   string `required:""`
+	}
+
+	u := User{Name: "abc", Secret: Secret{Token: "xyz"}, Skip: true}
+	fmt.Printf("%v %+v\n", sf.ReflectContext("guest", &u), u)
+
+	u = User{Name: "abc", Secret: Secret{Token: "xyz"}, Skip: true}
+	fmt.Printf("%v %+v\n", sf.ReflectContext("admin", &u), u)
+
+	// Output:
+	// <nil> {Name:abc Secret:{Token:} Skip:true Tail:}
+	// <nil> {Name:abc Secret:{Token:***} Skip:true Tail:}
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structs
+
+import (
+	"errors"
+	"expvar"
+	"reflect"
+	"time"
+
+	"github.com/xgfone/go-structs/handler"
+)
+
+// Trace is the information of a handler run on a struct field.
+type Trace struct {
+	Struct   reflect.Type  // The type of the struct containing the field.
+	Path     string        // The full path of the field, such as "Response.Persons[3].Username".
+	Tag      string        // The tag name, that's, the handler name.
+	Value    string        // The unquoted tag value.
+	Arg      any           // The argument parsed from the tag value.
+	Duration time.Duration // The time taken by the handler to run.
+	Err      error         // The original error or control-flow signal returned by the handler.
+}
+
+// Tracer is used to trace each handler run on a struct field,
+// which is called after the handler runs.
+type Tracer interface {
+	Trace(ctx any, trace Trace)
+}
+
+// TracerFunc is a function implementing the interface Tracer.
+type TracerFunc func(ctx any, trace Trace)
+
+// Trace implements the interface Tracer.
+func (f TracerFunc) Trace(ctx any, trace Trace) { f(ctx, trace) }
+
+// Metrics is a tracer counting the calls and errors of each handler,
+// which implements the interface expvar.Var, such as
+//
+//	{"calls": {"default": 10, "validate": 10}, "errors": {"validate": 1}}
+//
+// The control-flow signals, such as handler.SkipChildren, are not errors.
+type Metrics struct {
+	vars   expvar.Map
+	calls  expvar.Map
+	errors expvar.Map
+}
+
+// NewMetrics returns a new Metrics, which is published by expvar
+// with the name if it is not empty.
+//
+// Like expvar.Publish, it panics if the name is already registered.
+func NewMetrics(name string) *Metrics {
+	m := new(Metrics)
+	m.vars.Set("calls", &m.calls)
+	m.vars.Set("errors", &m.errors)
+	if name != "" {
+		expvar.Publish(name, m)
+	}
+	return m
+}
+
+// String implements the interface expvar.Var.
+func (m *Metrics) String() string { return m.vars.String() }
+
+// Trace implements the interface Tracer.
+func (m *Metrics) Trace(_ any, trace Trace) {
+	m.calls.Add(trace.Tag, 1)
+	if trace.Err != nil && !isControl(trace.Err) {
+		m.errors.Add(trace.Tag, 1)
+	}
+}
+
+// Calls returns the number of the calls of the handler named name.
+func (m *Metrics) Calls(name string) int64 { return getInt(&m.calls, name) }
+
+// Errors returns the number of the errors of the handler named name.
+func (m *Metrics) Errors(name string) int64 { return getInt(&m.errors, name) }
+
+func getInt(m *expvar.Map, name string) int64 {
+	if v, ok := m.Get(name).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+// isControl reports whether err is a control-flow signal, such as handler.SkipChildren.
+func isControl(err error) bool {
+	return errors.Is(err, handler.SkipChildren) ||
+		errors.Is(err, handler.SkipRemainingTags) ||
+		errors.Is(err, handler.StopAll)
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/xgfone/go-structs/handler"
+)
+
+func ExampleWithTracer() {
+	tracer := TracerFunc(func(_ any, t Trace) {
+		fmt.Printf("%s: struct=%s, tag=%s, arg=%v, err=%v\n", t.Path, t.Struct.Name(), t.Tag, t.Arg, t.Err)
+	})
+
+	sf := NewReflector(WithTracer(tracer))
+	sf.Register("min", handler.New(
+		func(s string) (any, error) { return strconv.Atoi(s) },
+		handler.SimpleRunner(func(v reflect.Value, arg interface{}) error {
+			if v.Int() < int64(arg.(int)) {
+				return errors.New("too small")
+			}
+			return nil
+		}),
+	))
+
+	type Item struct {
+		Count int `min:"1"`
+	}
+	type Order struct {
+		Items []Item
+	}
+
+	_ = sf.Reflect(&Order{Items: []Item{{Count: 1}, {Count: 0}}})
+
+	// Output:
+	// Order.Items[0].Count: struct=Item, tag=min, arg=1, err=<nil>
+	// Order.Items[1].Count: struct=Item, tag=min, arg=1, err=too small
+}
+
+func ExampleMetrics() {
+	metrics := NewMetrics("")
+	sf := NewReflector(WithTracer(metrics), CollectAllErrors(true))
+	sf.Register("required", handler.SimpleRunner(func(v reflect.Value, _ interface{}) error {
+		if v.IsZero() {
+			return errors.New("missing")
+		}
+		return nil
+	}))
+
+	type S struct {
+		F1 string `required:""`
+		F2 string `required:""`
+		F3 string `required:""`
+	}
+
+	_ = sf.Reflect(&S{F1: "a"})
+	fmt.Println(metrics.Calls("required"), metrics.Errors("required"))
+	fmt.Println(metrics)
+
+	// Output:
+	// 3 2
+	// {"calls": {"required": 3}, "errors": {"required": 2}}
+}
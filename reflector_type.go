@@ -0,0 +1,100 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/xgfone/go-structs/handler"
+)
+
+// RegisterType registers the type handler, which runs on every struct field
+// whose type is exactly t without any tag, such as normalizing all the fields
+// of time.Time to UTC. It is parsed with the empty tag value.
+//
+// The name of the type handler is "type:" + the full name with the package
+// path for the named type, such as "type:time.Time" or "type:math/rand/v2.Rand",
+// or "type:" + t.String() for the unnamed type, such as "type:[]int",
+// which is used by ReflectOnly, Overlay, FieldError.Tag, etc. And the options
+// are the same as Register, but with the option KeepTagOrder, the type handler
+// runs after the tag handlers of the field.
+//
+// A field can opt out of the type handler by the tag `reflect:"notype"`.
+//
+// If the reflector has been frozen, or another type with the same name
+// has been registered, such as the types declared in different functions
+// with the same name, it panics.
+func (r *Reflector) RegisterType(t reflect.Type, handler handler.Handler, options ...RegisterOption) {
+	r.updateTypeHandler(t, handler, options)
+}
+
+// UnregisterType unregisters the type handler by the type.
+//
+// If the reflector has been frozen, it panics.
+func (r *Reflector) UnregisterType(t reflect.Type) {
+	r.updateTypeHandler(t, nil, nil)
+}
+
+func (r *Reflector) updateTypeHandler(t reflect.Type, h handler.Handler, options []RegisterOption) {
+	r.planLock.Lock()
+	defer r.planLock.Unlock()
+
+	name := typeHandlerName(t)
+	if r.frozen.Load() {
+		panic(fmt.Errorf("the reflector has been frozen, and cannot update the handler '%s'", name))
+	}
+
+	if h == nil {
+		delete(r.typeHandlerMap, t)
+	} else {
+		for _t := range r.typeHandlerMap {
+			if _t != t && typeHandlerName(_t) == name {
+				panic(fmt.Errorf("the type handler '%s' has been registered for another type", name))
+			}
+		}
+
+		prev, exist := r.typeHandlerMap[t]
+		r.typeHandlerMap[t] = newHandlerEntry(h, prev, exist, options)
+	}
+
+	r.updateTypeHandlers()
+	r.resetCaches(name)
+}
+
+func (r *Reflector) updateTypeHandlers() {
+	handlers := make(map[reflect.Type]handlerEntry, len(r.typeHandlerMap))
+	for t, entry := range r.typeHandlerMap {
+		handlers[t] = entry
+	}
+	r.typeHandlers.Store(handlers)
+}
+
+func (r *Reflector) loadTypeHandler(t reflect.Type) (e handlerEntry, ok bool) {
+	e, ok = r.typeHandlers.Load().(map[reflect.Type]handlerEntry)[t]
+	return
+}
+
+func typeHandlerName(t reflect.Type) string {
+	if t.Name() != "" && t.PkgPath() != "" {
+		return "type:" + t.PkgPath() + "." + t.Name()
+	}
+	return "type:" + t.String()
+}
+
+// isTypeHandlerName reports whether name is the name of a type handler,
+// which cannot be a tag name because the tag name does not contain ":".
+func isTypeHandlerName(name string) bool { return strings.HasPrefix(name, "type:") }
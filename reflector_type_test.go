@@ -0,0 +1,97 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structs
+
+import (
+	"fmt"
+	randv1 "math/rand"
+	randv2 "math/rand/v2"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/xgfone/go-structs/handler"
+)
+
+func ExampleReflector_RegisterType() {
+	sf := NewReflector()
+	sf.RegisterType(reflect.TypeFor[time.Time](), handler.Typed(nil,
+		func(_ any, t *time.Time, _ string) error {
+			*t = t.UTC()
+			return nil
+		}))
+	sf.RegisterType(reflect.TypeFor[string](), handler.Typed(nil,
+		func(_ any, s *string, _ string) error {
+			*s = strings.TrimSpace(*s)
+			return nil
+		}), WithPriority(PriorityNormalize))
+	sf.Register("upper", handler.SimpleRunner(func(v reflect.Value, _ interface{}) error {
+		v.SetString(strings.ToUpper(v.String()))
+		return nil
+	}))
+
+	type Event struct {
+		Name     string `upper:""`
+		Raw      string `reflect:"notype"`
+		Time     time.Time
+		Children []Event
+	}
+
+	loc := time.FixedZone("UTC+8", 8*3600)
+	e := Event{
+		Name:     " start ",
+		Raw:      " raw ",
+		Time:     time.Date(2024, 1, 1, 8, 0, 0, 0, loc),
+		Children: []Event{{Name: " child ", Time: time.Date(2024, 1, 1, 9, 0, 0, 0, loc)}},
+	}
+
+	fmt.Println(sf.Reflect(&e))
+	fmt.Printf("%q %q %s\n", e.Name, e.Raw, e.Time)
+	fmt.Printf("%q %s\n", e.Children[0].Name, e.Children[0].Time)
+
+	info, _ := sf.Lookup("type:time.Time")
+	fmt.Println(info.Name, info.Type)
+
+	// Output:
+	// <nil>
+	// "START" " raw " 2024-01-01 00:00:00 +0000 UTC
+	// "CHILD" 2024-01-01 01:00:00 +0000 UTC
+	// type:time.Time time.Time
+}
+
+func ExampleReflector_RegisterType_collision() {
+	noop := handler.SimpleRunner(func(reflect.Value, interface{}) error { return nil })
+	sf := NewReflector()
+
+	// The types with the same name from different packages.
+	sf.RegisterType(reflect.TypeFor[randv1.Rand](), noop)
+	sf.RegisterType(reflect.TypeFor[randv2.Rand](), noop)
+	for _, info := range sf.Handlers() {
+		fmt.Println(info.Name, info.Type)
+	}
+
+	// The types declared in different functions have the same name.
+	t1 := func() reflect.Type { type ID int; return reflect.TypeFor[ID]() }()
+	t2 := func() reflect.Type { type ID int; return reflect.TypeFor[ID]() }()
+	sf.RegisterType(t1, noop)
+
+	defer func() { fmt.Println(recover()) }()
+	sf.RegisterType(t2, noop)
+
+	// Output:
+	// type:math/rand.Rand rand.Rand
+	// type:math/rand/v2.Rand rand.Rand
+	// the type handler 'type:github.com/xgfone/go-structs.ID' has been registered for another type
+}
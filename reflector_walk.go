@@ -0,0 +1,408 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xgfone/go-defaults"
+	"github.com/xgfone/go-structs/handler"
+)
+
+// pathSeg is a segment of the field path, which is either a struct
+// field name, an index of the slice or array, or a key of the map.
+type pathSeg struct {
+	field *reflect.StructField
+	index int
+	key   reflect.Value
+}
+
+// walker is the state of a reflection call,
+// which walks the struct value by the compiled plans.
+type walker struct {
+	r    *Reflector
+	ctx  any
+	root reflect.Value
+
+	callOptions
+	errs []error
+
+	phase int // The index of the current pass.
+	runs  int // The number of the handlers having run.
+
+	path []pathSeg
+
+	// visited records the pointers which have been visited,
+	// and the value is true when the pointer is being visited.
+	//
+	// The root pointer is always being visited, so it is recorded
+	// separately to avoid allocating the map for the common case.
+	visited map[ptrKey]bool
+	rootptr ptrKey
+}
+
+type ptrKey struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+var structDescent = &descent{kind: descendStruct}
+
+var walkerPool = sync.Pool{New: func() any {
+	return &walker{path: make([]pathSeg, 0, 8)}
+}}
+
+func getWalker(r *Reflector, ctx any) *walker {
+	w := walkerPool.Get().(*walker)
+	w.r, w.ctx = r, ctx
+	return w
+}
+
+// putWalker resets the walker and puts it back into the pool,
+// which only clears the states that have been used.
+func putWalker(w *walker) {
+	if len(w.visited) > 0 {
+		clear(w.visited)
+	}
+	if w.errs != nil {
+		w.errs = nil
+	}
+	if w.names != nil || w.overlay != nil {
+		w.callOptions = callOptions{}
+	}
+
+	// The path has been popped to empty after walking.
+	w.r, w.ctx, w.root, w.rootptr, w.runs = nil, nil, reflect.Value{}, ptrKey{}, 0
+	walkerPool.Put(w)
+}
+
+// walk reflects the struct or the pointer to struct v by its plan.
+func (w *walker) walk(v reflect.Value, plan *structPlan) (err error) {
+	if v.Kind() == reflect.Pointer {
+		w.rootptr = ptrKey{ptr: v.Pointer(), typ: v.Type()}
+		v = v.Elem()
+	}
+
+	w.root = v
+	passes := max(len(w.r.phases), 1)
+	for w.phase = 0; w.phase < passes; w.phase++ {
+		if w.phase > 0 {
+			clear(w.visited) // Each pass visits the pointers once.
+		}
+
+		if err = w.reflectPlan(v, plan); err == handler.StopAll {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+
+	// Only when collecting all the errors, they are recorded,
+	// which are always returned as a MultiError even if only one.
+	if len(w.errs) > 0 {
+		return MultiError(w.errs)
+	}
+	return nil
+}
+
+// fieldPath formats the current path of the field being reflected,
+// which starts with the name of the root struct type.
+func (w *walker) fieldPath() string {
+	return w.formatPath(w.root.Type().Name(), func(sf *reflect.StructField) string { return sf.Name })
+}
+
+// namePath is the same as fieldPath, but uses the names of the fields
+// returned by fieldName and does not start with the root struct type.
+func (w *walker) namePath() string {
+	return w.formatPath("", fieldName)
+}
+
+func (w *walker) formatPath(root string, name func(*reflect.StructField) string) string {
+	var b strings.Builder
+	b.WriteString(root)
+	for _, seg := range w.path {
+		if seg.key.IsValid() {
+			b.WriteByte('[')
+			fmt.Fprint(&b, seg.key.Interface())
+			b.WriteByte(']')
+		} else if seg.field == nil {
+			b.WriteByte('[')
+			b.WriteString(strconv.FormatInt(int64(seg.index), 10))
+			b.WriteByte(']')
+		} else {
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(name(seg.field))
+		}
+	}
+	return b.String()
+}
+
+// fieldName returns the name of the struct field by defaults.GetStructFieldName,
+// or the field name if it returns an empty name.
+func fieldName(sf *reflect.StructField) string {
+	if name, _ := defaults.GetStructFieldName(*sf); name != "" {
+		return name
+	}
+	return sf.Name
+}
+
+// field returns the struct field being reflected.
+func (w *walker) field() *reflect.StructField {
+	for i := len(w.path) - 1; i >= 0; i-- {
+		if w.path[i].field != nil {
+			return w.path[i].field
+		}
+	}
+	return nil
+}
+
+// fail wraps the error returned by the handler, or the error occurred
+// when walking the struct field if t is nil, as a FieldError.
+//
+// If collecting all the errors, it records the error and returns nil
+// to go on. Or, it returns the error directly.
+func (w *walker) fail(t *tagPlan, err error) error {
+	ferr := &FieldError{Path: w.fieldPath(), NamePath: w.namePath(), Err: err}
+	if sf := w.field(); sf != nil {
+		ferr.Field, ferr.Name = sf.Name, fieldName(sf)
+	}
+	if t != nil {
+		ferr.Tag = t.name
+		ferr.Value = t.value
+	}
+	return w.record(ferr)
+}
+
+// failConfig is the same as fail, but reports a configuration error.
+//
+// If the option ReturnConfigErrors is not enabled, it panics.
+func (w *walker) failConfig(err *ConfigError) error {
+	if !w.r.configErr {
+		panic(err)
+	}
+	return w.record(err)
+}
+
+// record records the error and returns nil if collecting all the errors.
+// Or, it returns the error directly.
+func (w *walker) record(err error) error {
+	if w.all {
+		w.errs = append(w.errs, err)
+		return nil
+	}
+	return err
+}
+
+func (w *walker) reflectStruct(v reflect.Value) error {
+	return w.reflectPlan(v, w.r.getFullPlan(v.Type(), w.overlay != nil))
+}
+
+func (w *walker) reflectPlan(v reflect.Value, plan *structPlan) (err error) {
+	for i := range plan.fields {
+		f := &plan.fields[i]
+		w.path = append(w.path, pathSeg{field: &f.field})
+		err = w.reflectField(v, f)
+		w.path = w.path[:len(w.path)-1]
+		if err != nil {
+			return err
+		}
+	}
+	return
+}
+
+func (w *walker) reflectField(sv reflect.Value, f *fieldPlan) (err error) {
+	v := sv.Field(f.index)
+	descend := !f.stop && f.descend != nil
+	for i := range f.tags {
+		if f.tags[i].phase != w.phase || !w.selected(f.tags[i].name) {
+			continue
+		}
+
+		ok, err := w.run(sv, v, f, &f.tags[i])
+		if err == handler.SkipChildren {
+			descend = false
+		} else if err != nil {
+			return err
+		} else if !ok {
+			break // Skip the rest handlers of the failed field.
+		}
+	}
+
+	if !descend {
+		return
+	}
+
+	if f.alloc && v.IsNil() && v.CanSet() && w.r.hasHandlers(f.field.Type.Elem()) {
+		v.Set(reflect.New(f.field.Type.Elem()))
+	}
+
+	return w.descend(v, f.descend)
+}
+
+// selected reports whether the handler named name is selected to run.
+func (w *walker) selected(name string) bool {
+	return w.names == nil || slices.Contains(w.names, name) != w.except
+}
+
+// run runs the handler of the tag t on the field v of the struct sv.
+//
+// If the handler fails, ok is false, and err is nil if collecting all the errors.
+// If the handler returns a control-flow signal, ok is false for
+// handler.SkipRemainingTags, or err is handler.SkipChildren or handler.StopAll.
+func (w *walker) run(sv, v reflect.Value, f *fieldPlan, t *tagPlan) (ok bool, err error) {
+	h, arg, cerr := t.handler, t.arg, t.err
+	if w.overlay != nil {
+		if oh, exist := w.overlay.handlers[t.name]; exist {
+			h = oh.runner
+			arg, cerr = w.overlay.getTagArg(sv.Type(), f, t, oh.parser)
+		}
+	}
+
+	switch {
+	case h == nil:
+		return true, nil
+	case cerr != nil:
+		return false, w.failConfig(cerr)
+	}
+
+	w.runs++
+	if w.r.tracer == nil {
+		err = h.Run(w.ctx, w.root, v, f.field, arg)
+	} else {
+		start := time.Now()
+		err = h.Run(w.ctx, w.root, v, f.field, arg)
+		w.r.tracer.Trace(w.ctx, Trace{Struct: sv.Type(), Path: w.fieldPath(), Tag: t.name,
+			Value: t.value, Arg: arg, Duration: time.Since(start), Err: err})
+	}
+
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, handler.SkipChildren):
+		return true, handler.SkipChildren
+	case errors.Is(err, handler.SkipRemainingTags):
+		return false, nil
+	case errors.Is(err, handler.StopAll):
+		return false, handler.StopAll
+	}
+
+	var perr handler.PanicError
+	if w.r.configErr && errors.As(err, &perr) {
+		return false, w.failConfig(&ConfigError{Type: sv.Type(),
+			Field: f.field.Name, Tag: t.name, Value: t.value, Err: perr})
+	}
+
+	return false, w.fail(t, err)
+}
+
+func (w *walker) descend(v reflect.Value, d *descent) (err error) {
+	switch d.kind {
+	case descendStruct:
+		err = w.reflectStruct(v)
+
+	case descendPointer:
+		if !v.IsNil() {
+			err = w.descendPointer(v, d.elem)
+		}
+
+	case descendElems:
+		for i, _len := 0, v.Len(); i < _len; i++ {
+			w.path = append(w.path, pathSeg{index: i})
+			err = w.descend(v.Index(i), d.elem)
+			w.path = w.path[:len(w.path)-1]
+			if err != nil {
+				break
+			}
+		}
+
+	case descendMap:
+		// The struct or array value in the map is not addressable,
+		// so copy it to reflect and set it back only if any handler
+		// has run on it, which avoids writing the read-only shared map.
+		var elem reflect.Value
+		kind := v.Type().Elem().Kind()
+		copied := kind == reflect.Struct || kind == reflect.Array
+		for iter := v.MapRange(); iter.Next(); {
+			key := iter.Key()
+			if !copied {
+				elem = iter.Value()
+			} else if elem.IsValid() {
+				elem.Set(iter.Value())
+			} else {
+				elem = reflect.New(v.Type().Elem()).Elem()
+				elem.Set(iter.Value())
+			}
+
+			runs := w.runs
+			w.path = append(w.path, pathSeg{key: key})
+			err = w.descend(elem, d.elem)
+			w.path = w.path[:len(w.path)-1]
+
+			if copied && w.runs != runs {
+				v.SetMapIndex(key, elem)
+			}
+
+			if err != nil {
+				break
+			}
+		}
+
+	case descendIface:
+		if !v.IsNil() {
+			if v = v.Elem(); v.Kind() == reflect.Pointer && !v.IsNil() && v.Elem().Kind() == reflect.Struct {
+				err = w.descendPointer(v, structDescent)
+			}
+		}
+	}
+
+	return
+}
+
+// descendPointer descends into the element of the non-nil pointer v,
+// which is visited only once during a reflection call.
+func (w *walker) descendPointer(v reflect.Value, elem *descent) (err error) {
+	key := ptrKey{ptr: v.Pointer(), typ: v.Type()}
+	if key == w.rootptr {
+		if w.r.cycleErr {
+			err = w.fail(nil, ErrCycle)
+		}
+		return
+	}
+
+	if visiting, ok := w.visited[key]; ok {
+		if visiting && w.r.cycleErr {
+			err = w.fail(nil, ErrCycle)
+		}
+		return
+	}
+
+	if w.visited == nil {
+		w.visited = make(map[ptrKey]bool, 8)
+	}
+
+	w.visited[key] = true
+	err = w.descend(v.Elem(), elem)
+	w.visited[key] = false
+	return
+}